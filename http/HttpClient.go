@@ -6,125 +6,111 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"net"
 	"net/http"
+	"sync"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/net/proxy"
 )
 
-func HttpClientGet(Uri, user, password string) (body []byte, err error) {
-	client := &http.Client{}
-	//r, _ := http.NewRequest("GET", urlStr, strings.NewReader(data.Encode())) // URL-encoded payload
-	r, _ := http.NewRequest("GET", Uri, nil) // URL-encoded payload
-	r.SetBasicAuth(user, password)
-	resp, err := client.Do(r)
+// defaultClient HttpClient* 系列函数共享的客户端
+var defaultClient, _ = NewClient()
+
+// socks5Clients 按代理地址复用的客户端
+var socks5Clients sync.Map
+
+// socks5Client ...
+func socks5Client(Socks5 string) (*Client, error) {
+	if c, ok := socks5Clients.Load(Socks5); ok {
+		return c.(*Client), nil
+	}
+	c, err := NewClient(WithProxy("socks5://" + Socks5))
 	if err != nil {
-		//log.Println(err.Error())
-		return body, err
+		return nil, err
+	}
+	actual, _ := socks5Clients.LoadOrStore(Socks5, c)
+	return actual.(*Client), nil
+}
+
+// redirectClient HttpClient 使用的不跟随重定向的客户端
+var redirectClient, _ = NewClient(WithoutRedirect(), WithTimeout(0))
+
+// readAll 读取并关闭响应体，不检查状态码
+func readAll(resp *http.Response, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
 	return io.ReadAll(resp.Body)
 }
+
+// HttpClientGet
+// Deprecated: 使用 NewClient(WithBasicAuth(user, password)).Get
+func HttpClientGet(Uri, user, password string) (body []byte, err error) {
+	r, err := defaultClient.NewRequest(context.Background(), http.MethodGet, Uri, nil)
+	if err != nil {
+		return body, err
+	}
+	r.SetBasicAuth(user, password)
+	return readAll(defaultClient.Do(r))
+}
 func Api301(c *gin.Context) {
 	c.Redirect(http.StatusMovedPermanently, "https://api.m.taobao.com/rest/api3.do?api=mtop.common.getTimestamp")
 }
+
+// HttpClientGitlabGet
+// Deprecated: 使用 gitlab.NewClient，自动分页及限流
 func HttpClientGitlabGet(Uri, Token string) (body []byte, err error) {
-	client := &http.Client{}
-	//r, _ := http.NewRequest("GET", urlStr, strings.NewReader(data.Encode())) // URL-encoded payload
-	r, err := http.NewRequest("GET", Uri, nil) // URL-encoded payload
+	r, err := defaultClient.NewRequest(context.Background(), http.MethodGet, Uri, nil)
 	if err != nil {
 		return body, err
 	}
 	r.Header.Add("PRIVATE-TOKEN", Token)
-	resp, err := client.Do(r)
+	body, err = readAll(defaultClient.Do(r))
 	if err != nil {
 		log.Println(err.Error())
-		//return Pages, body, err
-		return body, err
 	}
-	defer resp.Body.Close()
-	//获取Header头
-	//v, ok := resp.Header["X-Total-Pages"]
-	//if ok {
-	//	Pages = v[0]
-	//}
-	//log.Println(io.ReadAll(resp.Body))
-	//body, err = io.ReadAll(resp.Body)
-	//return Pages, body, err
-	return io.ReadAll(resp.Body)
+	return body, err
 }
+
+// HttpClientGetSOCKS5
+// Deprecated: 使用 NewClient(WithProxy("socks5://" + Socks5)).Get
 func HttpClientGetSOCKS5(Uri, Socks5 string) (body []byte, err error) {
-	// 创建一个 SOCKS5 代理拨号器
-	dialer, err := proxy.SOCKS5("tcp", Socks5, nil, proxy.Direct)
+	client, err := socks5Client(Socks5)
 	if err != nil {
-		return nil, fmt.Errorf("无法连接代理: %v", err)
-	}
-	// // 设置一个 HTTP 客户端
-	// httpClient := &http.Client{
-	// 	Transport: &http.Transport{
-	// 		Dial: dialer.Dial,
-	// 	},
-	// }
-	// 创建一个带有 DialContext 的 HTTP 客户端
-	httpClient := &http.Client{
-		Transport: &http.Transport{
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				return dialer.Dial(network, addr)
-			},
-		},
+		return nil, fmt.Errorf("无法连接代理: %w", err)
 	}
 
 	// 发起 HTTP GET 请求
-	r, err := httpClient.Get(Uri)
+	body, err = readAll(client.Get(context.Background(), Uri))
 	if err != nil {
-		return nil, fmt.Errorf("HTTP请求失败: %v", err)
+		return nil, fmt.Errorf("HTTP请求失败: %w", err)
 	}
-	defer r.Body.Close()
-
-	// 读取响应内容
-	return io.ReadAll(r.Body)
+	return body, nil
 }
+
+// HttpClientPost
+// Deprecated: 使用 NewClient().Post
 func HttpClientPost(Uri string, reader *bytes.Reader) (body []byte, err error) {
-	client := &http.Client{}
-	//data := Tokens_Create{
-	//	Name:   "api",
-	//	Scopes: []string{"api"},
-	//}
-	//stu, err := json.Marshal(&data)
-	//reader := bytes.NewReader(stu)
-	r, err := http.NewRequest("POST", Uri, reader) // URL-encoded payload
-	//增加header选项
-	r.Header.Set("Content-Type", "application/json")
-	//r.SetBasicAuth(user, password)
+	r, err := defaultClient.NewRequest(context.Background(), http.MethodPost, Uri, reader)
 	if err != nil {
 		log.Println("创建NewRequest客户端失败")
 		return body, err
 	}
+	//增加header选项
+	r.Header.Set("Content-Type", "application/json")
 	//处理返回结果
-	resp, err := client.Do(r)
+	body, err = readAll(defaultClient.Do(r))
 	if err != nil {
 		log.Println("发起Http_Client_Post请求失败")
-		return body, err
 	}
-	defer resp.Body.Close()
-	return io.ReadAll(resp.Body)
+	return body, err
 }
 
+// HttpClient 发送请求且不跟随重定向，调用方负责关闭响应体
 func HttpClient(Method, Uri string, Body io.Reader) (resp *http.Response, err error) {
-	client := &http.Client{
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
-	//r, _ := http.NewRequest("GET", urlStr, strings.NewReader(data.Encode())) // URL-encoded payload
-	r, _ := http.NewRequest(Method, Uri, Body) // URL-encoded payload
-
-	resp, err = client.Do(r)
+	r, err := redirectClient.NewRequest(context.Background(), Method, Uri, Body)
 	if err != nil {
-		//log.Println(err.Error())
-		return resp, err
+		return nil, err
 	}
-	defer resp.Body.Close()
-	return resp, nil
+	return redirectClient.Do(r)
 }
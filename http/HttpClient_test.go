@@ -11,6 +11,6 @@ func TestHttpClient(t *testing.T) {
 	body, _ = HttpClientGet("https://cip.cc", "", "")
 	fmt.Println(string(body))
 
-	body, _ = HttpClientGitlabGet("https://gitlab.xxx/api/v4/projects?per_page=50000&search=xxx", "xxx")
+	body, _ = HttpClientGitlabGet("https://gitlab.xxx/api/v4/projects?per_page=100&search=xxx", "xxx")
 	fmt.Println(string(body))
 }
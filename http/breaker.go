@@ -0,0 +1,219 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器打开，请求未发出
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+const (
+	// BreakerFailureThreshold 默认连续失败多少次后熔断
+	BreakerFailureThreshold = 5
+	// BreakerOpenTimeout 默认熔断持续时间，之后进入半开状态放行探测请求
+	BreakerOpenTimeout = 30 * time.Second
+	// BreakerHalfOpenRequests 默认半开状态下同时放行的探测请求数
+	BreakerHalfOpenRequests = 1
+)
+
+// BreakerPolicy 熔断策略，零值字段使用默认值
+type BreakerPolicy struct {
+	// FailureThreshold 连续失败多少次后熔断
+	FailureThreshold int
+	// OpenTimeout 熔断持续时间
+	OpenTimeout time.Duration
+	// HalfOpenRequests 半开状态下同时放行的探测请求数，探测成功后恢复，失败则重新熔断
+	HalfOpenRequests int
+	// IsFailure 判断请求是否失败，默认网络错误及5xx响应为失败
+	IsFailure func(resp *http.Response, err error) bool
+}
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	// BreakerClosed 正常放行
+	BreakerClosed BreakerState = iota
+	// BreakerOpen 熔断中，请求直接返回 ErrCircuitOpen
+	BreakerOpen
+	// BreakerHalfOpen 放行有限的探测请求
+	BreakerHalfOpen
+)
+
+// String ...
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// breaker 单个主机的熔断器
+type breaker struct {
+	mutex    sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+// CircuitBreakers 按主机(host:port)独立熔断的中间件
+type CircuitBreakers struct {
+	policy   BreakerPolicy
+	mutex    sync.Mutex
+	breakers map[string]*breaker
+}
+
+// NewCircuitBreakers ...
+func NewCircuitBreakers(policy BreakerPolicy) *CircuitBreakers {
+	if policy.FailureThreshold <= 0 {
+		policy.FailureThreshold = BreakerFailureThreshold
+	}
+	if policy.OpenTimeout <= 0 {
+		policy.OpenTimeout = BreakerOpenTimeout
+	}
+	if policy.HalfOpenRequests <= 0 {
+		policy.HalfOpenRequests = BreakerHalfOpenRequests
+	}
+	if policy.IsFailure == nil {
+		policy.IsFailure = func(resp *http.Response, err error) bool {
+			return err != nil || resp.StatusCode >= http.StatusInternalServerError
+		}
+	}
+
+	return &CircuitBreakers{
+		policy:   policy,
+		breakers: make(map[string]*breaker),
+	}
+}
+
+// CircuitBreaker 按主机熔断中间件，与 Retry 同时使用时应放在 Retry 内层
+func CircuitBreaker(policy BreakerPolicy) TransportMiddleware {
+	return NewCircuitBreakers(policy).Middleware
+}
+
+// State 主机当前的熔断状态
+func (cb *CircuitBreakers) State(host string) BreakerState {
+	b := cb.breaker(host)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= cb.policy.OpenTimeout {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// breaker ...
+func (cb *CircuitBreakers) breaker(host string) *breaker {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	b, ok := cb.breakers[host]
+	if !ok {
+		b = &breaker{}
+		cb.breakers[host] = b
+	}
+	return b
+}
+
+// Middleware ...
+func (cb *CircuitBreakers) Middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		b := cb.breaker(r.URL.Host)
+		probe, ok := cb.allow(b)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, r.URL.Host)
+		}
+
+		resp, err := next.RoundTrip(r)
+		// 调用方取消的请求不代表主机故障，也不代表恢复，只释放探测名额
+		if r.Context().Err() != nil {
+			cb.release(b, probe)
+			return resp, err
+		}
+		cb.done(b, probe, cb.policy.IsFailure(resp, err))
+		return resp, err
+	})
+}
+
+// allow 判断是否放行，probe 表示本次为半开状态下的探测请求
+func (cb *CircuitBreakers) allow(b *breaker) (probe, ok bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < cb.policy.OpenTimeout {
+			return false, false
+		}
+		b.state = BreakerHalfOpen
+		b.probes = 0
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probes >= cb.policy.HalfOpenRequests {
+			return false, false
+		}
+		b.probes++
+		return true, true
+	}
+	return false, true
+}
+
+// release 释放探测名额，不改变状态及失败计数
+func (cb *CircuitBreakers) release(b *breaker, probe bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if probe && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// done 记录请求结果
+func (cb *CircuitBreakers) done(b *breaker, probe, failed bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if probe && b.probes > 0 {
+		b.probes--
+	}
+	if !failed {
+		if b.state == BreakerHalfOpen && probe {
+			b.state = BreakerClosed
+		}
+		if b.state == BreakerClosed {
+			b.failures = 0
+		}
+		return
+	}
+
+	switch b.state {
+	case BreakerClosed:
+		b.failures++
+		if b.failures >= cb.policy.FailureThreshold {
+			b.open()
+		}
+	case BreakerHalfOpen:
+		if probe {
+			b.open()
+		}
+	}
+}
+
+// open ...
+func (b *breaker) open() {
+	b.state = BreakerOpen
+	b.openedAt = time.Now()
+	b.failures = 0
+	b.probes = 0
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreaker ...
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		calls   int32
+		healthy atomic.Bool
+		release = make(chan struct{})
+		blocked atomic.Bool
+	)
+	next := RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		if blocked.Load() {
+			<-release
+		}
+		if r.URL.Host == "down" && !healthy.Load() {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+
+	cb := NewCircuitBreakers(BreakerPolicy{FailureThreshold: 3, OpenTimeout: 50 * time.Millisecond})
+	rt := cb.Middleware(next)
+	get := func(host string) error {
+		_, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://"+host+"/", nil))
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := get("down"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected transport error, got %v", err)
+		}
+	}
+	if cb.State("down") != BreakerOpen {
+		t.Fatalf("expected open, got %s", cb.State("down"))
+	}
+	atomic.StoreInt32(&calls, 0)
+	if err := get("down"); !errors.Is(err, ErrCircuitOpen) || atomic.LoadInt32(&calls) != 0 {
+		t.Fatalf("expected ErrCircuitOpen without request, got %v", err)
+	}
+	// 其他主机不受影响
+	if err := get("up"); err != nil {
+		t.Fatalf("expected other host allowed, got %v", err)
+	}
+
+	// 半开探测失败后重新熔断
+	time.Sleep(60 * time.Millisecond)
+	if cb.State("down") != BreakerHalfOpen {
+		t.Fatalf("expected half-open, got %s", cb.State("down"))
+	}
+	if err := get("down"); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected probe sent, got %v", err)
+	}
+	if err := get("down"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected reopened, got %v", err)
+	}
+
+	// 半开状态只放行一个探测请求，探测成功后恢复
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+	blocked.Store(true)
+	probed := make(chan error, 1)
+	go func() { probed <- get("down") }()
+	for atomic.LoadInt32(&calls) < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := get("down"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected concurrent request rejected while probing, got %v", err)
+	}
+	blocked.Store(false)
+	close(release)
+	if err := <-probed; err != nil {
+		t.Fatalf("expected probe success, got %v", err)
+	}
+	if cb.State("down") != BreakerClosed || get("down") != nil {
+		t.Fatalf("expected closed, got %s", cb.State("down"))
+	}
+}
+
+// TestCircuitBreakerCanceled ...
+func TestCircuitBreakerCanceled(t *testing.T) {
+	var healthy atomic.Bool
+	next := RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if !healthy.Load() {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	cb := NewCircuitBreakers(BreakerPolicy{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond})
+	rt := cb.Middleware(next)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	get := func(ctx context.Context) error {
+		_, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://host/", nil).WithContext(ctx))
+		return err
+	}
+
+	// 关闭状态下取消的请求不清零失败计数
+	get(context.Background())
+	healthy.Store(true)
+	get(canceled)
+	healthy.Store(false)
+	get(context.Background())
+	if cb.State("host") != BreakerOpen {
+		t.Fatalf("expected open, got %s", cb.State("host"))
+	}
+
+	// 取消的探测请求不恢复熔断器，并释放探测名额
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+	if err := get(canceled); errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected probe sent, got %v", err)
+	}
+	if cb.State("host") != BreakerHalfOpen {
+		t.Fatalf("expected still half-open, got %s", cb.State("host"))
+	}
+	if err := get(context.Background()); err != nil || cb.State("host") != BreakerClosed {
+		t.Fatalf("expected next probe allowed and closed, got %s err: %v", cb.State("host"), err)
+	}
+}
+
+// TestRetryWithCircuitBreaker ...
+func TestRetryWithCircuitBreaker(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient(WithTransportMiddleware(
+		Retry(RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}),
+		CircuitBreaker(BreakerPolicy{FailureThreshold: 2, OpenTimeout: time.Minute}),
+	))
+	// 熔断后不再重试
+	_, err := c.Get(context.Background(), ts.URL)
+	if !errors.Is(err, ErrCircuitOpen) || atomic.LoadInt32(&hits) != 2 {
+		t.Fatalf("expected circuit open after 2 attempts, got %v after %d", err, hits)
+	}
+}
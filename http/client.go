@@ -0,0 +1,323 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	// DefaultTimeout 单次请求(含读取响应体)的默认超时时间
+	DefaultTimeout = 30 * time.Second
+	// DefaultDialTimeout 默认建立连接超时时间
+	DefaultDialTimeout = 10 * time.Second
+)
+
+// ClientOption 客户端配置
+type ClientOption func(o *clientOptions)
+
+// clientOptions ...
+type clientOptions struct {
+	baseURL               string
+	timeout               time.Duration
+	dialTimeout           time.Duration
+	responseHeaderTimeout time.Duration
+	header                http.Header
+	auth                  func(r *http.Request)
+	proxy                 string
+	tlsConfig             *tls.Config
+	noRedirect            bool
+	middlewares           []TransportMiddleware
+}
+
+// WithBaseURL 相对路径基于该地址拼接，如 "https://gitlab.example.com/api/v4"
+func WithBaseURL(baseURL string) ClientOption {
+	return func(o *clientOptions) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithTimeout 单次请求(含读取响应体)的超时时间，0 为不超时
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithDialTimeout 建立连接超时时间
+func WithDialTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.dialTimeout = timeout
+	}
+}
+
+// WithResponseHeaderTimeout 发送请求后等待响应头的超时时间
+func WithResponseHeaderTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.responseHeaderTimeout = timeout
+	}
+}
+
+// WithHeader 每个请求默认携带的请求头
+func WithHeader(key, value string) ClientOption {
+	return func(o *clientOptions) {
+		o.header.Add(key, value)
+	}
+}
+
+// WithBasicAuth 使用 Basic 认证
+func WithBasicAuth(user, password string) ClientOption {
+	return func(o *clientOptions) {
+		o.auth = func(r *http.Request) {
+			r.SetBasicAuth(user, password)
+		}
+	}
+}
+
+// WithBearerToken 使用 Authorization: Bearer 认证
+func WithBearerToken(token string) ClientOption {
+	return func(o *clientOptions) {
+		o.auth = func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+}
+
+// WithPrivateToken 使用 GitLab PRIVATE-TOKEN 认证
+func WithPrivateToken(token string) ClientOption {
+	return func(o *clientOptions) {
+		o.auth = func(r *http.Request) {
+			r.Header.Set("PRIVATE-TOKEN", token)
+		}
+	}
+}
+
+// WithProxy 使用代理，支持 http://、https://、socks5:// 及 socks5h://，如 "socks5://127.0.0.1:1080"
+// 未配置时使用环境变量 HTTP_PROXY、HTTPS_PROXY、NO_PROXY
+func WithProxy(proxyURL string) ClientOption {
+	return func(o *clientOptions) {
+		o.proxy = proxyURL
+	}
+}
+
+// WithTLSConfig 自定义TLS配置，如自签证书的 RootCAs 或客户端证书
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(o *clientOptions) {
+		o.tlsConfig = config
+	}
+}
+
+// WithoutRedirect 不跟随重定向，直接返回3xx响应
+func WithoutRedirect() ClientOption {
+	return func(o *clientOptions) {
+		o.noRedirect = true
+	}
+}
+
+// WithTransportMiddleware 在连接池 Transport 外层依次包装中间件，第一个为最外层
+// 如 WithTransportMiddleware(Retry(RetryPolicy{}), CircuitBreaker(BreakerPolicy{}))
+func WithTransportMiddleware(middlewares ...TransportMiddleware) ClientOption {
+	return func(o *clientOptions) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// RoundTripperFunc 函数形式的 http.RoundTripper
+type RoundTripperFunc func(r *http.Request) (*http.Response, error)
+
+// RoundTrip ...
+func (f RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// TransportMiddleware 包装 http.RoundTripper，用于重试、熔断、日志等
+type TransportMiddleware func(next http.RoundTripper) http.RoundTripper
+
+// Chain 依次包装中间件，第一个为最外层
+func Chain(rt http.RoundTripper, middlewares ...TransportMiddleware) http.RoundTripper {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		rt = middlewares[i](rt)
+	}
+	return rt
+}
+
+// Client 可复用的http客户端，并发安全；所有请求共享同一个带连接池的 Transport
+type Client struct {
+	client  *http.Client
+	baseURL *url.URL
+	header  http.Header
+	auth    func(r *http.Request)
+}
+
+// NewClient ...
+func NewClient(opts ...ClientOption) (*Client, error) {
+	o := &clientOptions{
+		timeout:     DefaultTimeout,
+		dialTimeout: DefaultDialTimeout,
+		header:      make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	c := &Client{
+		header: o.header,
+		auth:   o.auth,
+	}
+	if o.baseURL != "" {
+		baseURL, err := url.Parse(o.baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base url: %w", err)
+		}
+		// 保证相对路径拼接在基础路径之后，而不是替换其最后一段
+		if !strings.HasSuffix(baseURL.Path, "/") {
+			baseURL.Path += "/"
+		}
+		c.baseURL = baseURL
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   o.dialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		ResponseHeaderTimeout: o.responseHeaderTimeout,
+		TLSClientConfig:       o.tlsConfig,
+	}
+	if o.proxy != "" {
+		proxyURL, err := url.Parse(o.proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy: %w", err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("invalid proxy: unsupported scheme %q", proxyURL.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	c.client = &http.Client{
+		Transport: Chain(transport, o.middlewares...),
+		Timeout:   o.timeout,
+	}
+	if o.noRedirect {
+		c.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return c, nil
+}
+
+// resolve 相对路径基于 baseURL 拼接，完整地址直接使用
+func (c *Client) resolve(path string) (string, error) {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+	if c.baseURL == nil || ref.IsAbs() {
+		return path, nil
+	}
+	// 保留路径中的转义字符，如 GitLab 项目路径中的 %2F
+	if ref, err = url.Parse(strings.TrimPrefix(path, "/")); err != nil {
+		return "", err
+	}
+	return c.baseURL.ResolveReference(ref).String(), nil
+}
+
+// NewRequest 创建请求，附加默认请求头及认证信息；返回后仍可修改请求头
+func (c *Client) NewRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	uri, err := c.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := http.NewRequestWithContext(ctx, method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range c.header {
+		r.Header[key] = append([]string(nil), values...)
+	}
+	if c.auth != nil {
+		c.auth(r)
+	}
+	return r, nil
+}
+
+// Do 发送请求，调用方负责关闭响应体
+func (c *Client) Do(r *http.Request) (*http.Response, error) {
+	return c.client.Do(r)
+}
+
+// Get 发送 GET 请求，调用方负责关闭响应体
+func (c *Client) Get(ctx context.Context, path string) (*http.Response, error) {
+	r, err := c.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(r)
+}
+
+// Post 发送 POST 请求，调用方负责关闭响应体
+func (c *Client) Post(ctx context.Context, path, contentType string, body io.Reader) (*http.Response, error) {
+	r, err := c.NewRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", contentType)
+	return c.Do(r)
+}
+
+// JSON 以JSON编码 in 作为请求体(in 为 nil 时无请求体)，并将2xx响应解码到 out(out 为 nil 时丢弃响应体)
+// 非2xx响应返回 *HTTPError
+func (c *Client) JSON(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	r, err := c.NewRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	r.Header.Set("Accept", "application/json")
+
+	resp, err := c.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err = CheckResponse(resp); err != nil {
+		return err
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,186 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestClient ...
+func TestClient(t *testing.T) {
+	var conns int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/projects/group/app":
+			if r.URL.EscapedPath() != "/api/v4/projects/group%2Fapp" {
+				t.Errorf("expected escaped path kept, got %s", r.URL.EscapedPath())
+			}
+			if r.Header.Get("PRIVATE-TOKEN") != "token" || r.Header.Get("X-Client") != "go-utils" {
+				t.Errorf("unexpected headers %v", r.Header)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"id":1,"name":"app"}`)
+		case "/api/v4/echo":
+			if r.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("unexpected content type %s", r.Header.Get("Content-Type"))
+			}
+			io.Copy(w, r.Body)
+		case "/api/v4/missing":
+			http.Error(w, "not found", http.StatusNotFound)
+		case "/api/v4/slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	ts.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	c, err := NewClient(
+		WithBaseURL(ts.URL+"/api/v4"),
+		WithHeader("X-Client", "go-utils"),
+		WithPrivateToken("token"),
+		WithTimeout(100*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("new client fail, err: %v", err)
+	}
+	ctx := context.Background()
+
+	var project struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	for i := 0; i < 3; i++ {
+		if err = c.JSON(ctx, http.MethodGet, "/projects/group%2Fapp", nil, &project); err != nil || project.Name != "app" {
+			t.Fatalf("get json fail, got %+v err: %v", project, err)
+		}
+	}
+	// 复用连接池
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Fatalf("expected 1 connection, got %d", n)
+	}
+
+	var echo map[string]int
+	if err = c.JSON(ctx, http.MethodPost, "echo", map[string]int{"a": 1}, &echo); err != nil || echo["a"] != 1 {
+		t.Fatalf("post json fail, got %v err: %v", echo, err)
+	}
+
+	if err = c.JSON(ctx, http.MethodGet, "missing", nil, nil); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected status error, got %v", err)
+	}
+
+	// 超时及ctx取消
+	if _, err = c.Get(ctx, "slow"); err == nil {
+		t.Fatalf("expected timeout")
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = c.Get(canceled, "echo"); err == nil {
+		t.Fatalf("expected canceled")
+	}
+
+	// 完整地址不拼接 baseURL
+	resp, err := c.Post(ctx, ts.URL+"/api/v4/echo", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("post fail, err: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "{}" {
+		t.Fatalf("unexpected body %s", body)
+	}
+}
+
+// TestClientAuth ...
+func TestClientAuth(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("Authorization"))
+	}))
+	defer ts.Close()
+
+	for want, opt := range map[string]ClientOption{
+		"Basic " + base64.StdEncoding.EncodeToString([]byte("user:password")): WithBasicAuth("user", "password"),
+		"Bearer token": WithBearerToken("token"),
+	} {
+		c, _ := NewClient(opt)
+		resp, err := c.Get(context.Background(), ts.URL)
+		if err != nil {
+			t.Fatalf("get fail, err: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != want {
+			t.Fatalf("expected %s, got %s", want, body)
+		}
+	}
+}
+
+// TestClientTransport ...
+func TestClientTransport(t *testing.T) {
+	ctx := context.Background()
+
+	// TLS
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "tls")
+	}))
+	defer tlsServer.Close()
+	c, _ := NewClient()
+	if _, err := c.Get(ctx, tlsServer.URL); err == nil {
+		t.Fatalf("expected unknown authority")
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(tlsServer.Certificate())
+	c, _ = NewClient(WithTLSConfig(&tls.Config{RootCAs: pool}))
+	if _, err := c.Get(ctx, tlsServer.URL); err != nil {
+		t.Fatalf("tls get fail, err: %v", err)
+	}
+
+	// HTTP代理
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		io.WriteString(w, r.URL.String())
+	}))
+	defer proxy.Close()
+	c, err := NewClient(WithProxy(proxy.URL))
+	if err != nil {
+		t.Fatalf("new client fail, err: %v", err)
+	}
+	resp, err := c.Get(ctx, "http://example.invalid/path")
+	if err != nil {
+		t.Fatalf("proxy get fail, err: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if atomic.LoadInt32(&proxied) != 1 || string(body) != "http://example.invalid/path" {
+		t.Fatalf("expected request via proxy, got %s", body)
+	}
+
+	// 重定向
+	redirect := httptest.NewServer(http.RedirectHandler("/target", http.StatusFound))
+	defer redirect.Close()
+	c, _ = NewClient(WithoutRedirect())
+	if resp, err = c.Get(ctx, redirect.URL); err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected 302, got %v err: %v", resp, err)
+	}
+	resp.Body.Close()
+
+	for _, opt := range []ClientOption{WithProxy("ftp://127.0.0.1"), WithBaseURL("://")} {
+		if _, err = NewClient(opt); err == nil {
+			t.Fatalf("expected invalid option error")
+		}
+	}
+}
@@ -0,0 +1,431 @@
+// Package gitlab GitLab v4 API 客户端，自动分页并遵循 RateLimit-* 限流响应头
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	xhttp "github.com/chenpeicheng3804/go-utils/http"
+)
+
+const (
+	// DefaultPerPage 默认每页数量，GitLab 允许的最大值
+	DefaultPerPage = 100
+	// RateLimitAttempts 429响应最多请求次数(含首次)
+	RateLimitAttempts = 3
+)
+
+// Client GitLab v4 API 客户端，并发安全
+type Client struct {
+	client *xhttp.Client
+	limit  rateLimit
+}
+
+// NewClient baseURL 为 GitLab 地址，如 "https://gitlab.example.com"，token 为 Personal/Project Access Token
+// opts 用于配置超时、代理、重试等，如 xhttp.WithTransportMiddleware(xhttp.Retry(xhttp.RetryPolicy{}))
+func NewClient(baseURL, token string, opts ...xhttp.ClientOption) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid gitlab url %q", baseURL)
+	}
+	api := strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/api/v4") + "/api/v4"
+
+	client, err := xhttp.NewClient(append([]xhttp.ClientOption{
+		xhttp.WithBaseURL(api),
+		xhttp.WithPrivateToken(token),
+	}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		client: client,
+		limit:  rateLimit{remaining: -1},
+	}, nil
+}
+
+// ListOptions 列表通用参数
+type ListOptions struct {
+	PerPage int    // 每页数量，默认 DefaultPerPage
+	OrderBy string // 排序字段，如 id、name、updated_at
+	Sort    string // asc、desc
+}
+
+// values ...
+func (o ListOptions) values() url.Values {
+	v := url.Values{}
+	if o.PerPage <= 0 {
+		o.PerPage = DefaultPerPage
+	}
+	v.Set("per_page", strconv.Itoa(o.PerPage))
+	if o.OrderBy != "" {
+		v.Set("order_by", o.OrderBy)
+	}
+	if o.Sort != "" {
+		v.Set("sort", o.Sort)
+	}
+	return v
+}
+
+// ProjectOptions 项目列表参数
+type ProjectOptions struct {
+	ListOptions
+	Search     string
+	Owned      bool  // 仅当前用户拥有的项目
+	Membership bool  // 仅当前用户为成员的项目
+	Archived   *bool // nil 为不过滤
+}
+
+// values ...
+func (o *ProjectOptions) values() url.Values {
+	if o == nil {
+		o = &ProjectOptions{}
+	}
+	v := o.ListOptions.values()
+	if o.Search != "" {
+		v.Set("search", o.Search)
+	}
+	if o.Owned {
+		v.Set("owned", "true")
+	}
+	if o.Membership {
+		v.Set("membership", "true")
+	}
+	if o.Archived != nil {
+		v.Set("archived", strconv.FormatBool(*o.Archived))
+	}
+	return v
+}
+
+// GroupOptions 群组列表参数
+type GroupOptions struct {
+	ListOptions
+	Search       string
+	Owned        bool
+	AllAvailable bool // 包含所有可见群组，而非仅当前用户所属群组
+}
+
+// values ...
+func (o *GroupOptions) values() url.Values {
+	if o == nil {
+		o = &GroupOptions{}
+	}
+	v := o.ListOptions.values()
+	if o.Search != "" {
+		v.Set("search", o.Search)
+	}
+	if o.Owned {
+		v.Set("owned", "true")
+	}
+	if o.AllAvailable {
+		v.Set("all_available", "true")
+	}
+	return v
+}
+
+// MemberOptions 成员列表参数
+type MemberOptions struct {
+	ListOptions
+	Query     string // 按用户名或姓名搜索
+	Inherited bool   // 包含从上级群组继承的成员
+}
+
+// values ...
+func (o *MemberOptions) values() url.Values {
+	if o == nil {
+		o = &MemberOptions{}
+	}
+	v := o.ListOptions.values()
+	if o.Query != "" {
+		v.Set("query", o.Query)
+	}
+	return v
+}
+
+// path ...
+func (o *MemberOptions) path(prefix string) string {
+	if o != nil && o.Inherited {
+		return prefix + "/members/all"
+	}
+	return prefix + "/members"
+}
+
+// PipelineOptions 流水线列表参数
+type PipelineOptions struct {
+	ListOptions
+	Status string // running、pending、success、failed、canceled 等
+	Ref    string
+	Source string // push、schedule、merge_request_event 等
+}
+
+// values ...
+func (o *PipelineOptions) values() url.Values {
+	if o == nil {
+		o = &PipelineOptions{}
+	}
+	v := o.ListOptions.values()
+	if o.Status != "" {
+		v.Set("status", o.Status)
+	}
+	if o.Ref != "" {
+		v.Set("ref", o.Ref)
+	}
+	if o.Source != "" {
+		v.Set("source", o.Source)
+	}
+	return v
+}
+
+// pathID 项目或群组ID，支持数字ID及完整路径(如 "group/project")
+func pathID(id interface{}) (string, error) {
+	switch v := id.(type) {
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case string:
+		return url.PathEscape(v), nil
+	}
+	return "", fmt.Errorf("invalid id type %T, expected int or string", id)
+}
+
+// Projects 遍历项目
+func (c *Client) Projects(ctx context.Context, opts *ProjectOptions) iter.Seq2[*Project, error] {
+	return list[Project](ctx, c, "projects", "projects", opts.values())
+}
+
+// Project pid 为项目ID或完整路径
+func (c *Client) Project(ctx context.Context, pid interface{}) (*Project, error) {
+	id, err := pathID(pid)
+	if err != nil {
+		return nil, err
+	}
+	project := &Project{}
+	_, err = c.get(ctx, "projects/:id", "projects/"+id, project)
+	return project, err
+}
+
+// Groups 遍历群组
+func (c *Client) Groups(ctx context.Context, opts *GroupOptions) iter.Seq2[*Group, error] {
+	return list[Group](ctx, c, "groups", "groups", opts.values())
+}
+
+// Group gid 为群组ID或完整路径
+func (c *Client) Group(ctx context.Context, gid interface{}) (*Group, error) {
+	id, err := pathID(gid)
+	if err != nil {
+		return nil, err
+	}
+	group := &Group{}
+	_, err = c.get(ctx, "groups/:id", "groups/"+id, group)
+	return group, err
+}
+
+// GroupProjects 遍历群组下的项目
+func (c *Client) GroupProjects(ctx context.Context, gid interface{}, opts *ProjectOptions) iter.Seq2[*Project, error] {
+	id, err := pathID(gid)
+	if err != nil {
+		return fail[Project](err)
+	}
+	return list[Project](ctx, c, "groups/:id/projects", "groups/"+id+"/projects", opts.values())
+}
+
+// ProjectMembers 遍历项目成员
+func (c *Client) ProjectMembers(ctx context.Context, pid interface{}, opts *MemberOptions) iter.Seq2[*Member, error] {
+	id, err := pathID(pid)
+	if err != nil {
+		return fail[Member](err)
+	}
+	return list[Member](ctx, c, opts.path("projects/:id"), opts.path("projects/"+id), opts.values())
+}
+
+// GroupMembers 遍历群组成员
+func (c *Client) GroupMembers(ctx context.Context, gid interface{}, opts *MemberOptions) iter.Seq2[*Member, error] {
+	id, err := pathID(gid)
+	if err != nil {
+		return fail[Member](err)
+	}
+	return list[Member](ctx, c, opts.path("groups/:id"), opts.path("groups/"+id), opts.values())
+}
+
+// Pipelines 遍历项目流水线
+func (c *Client) Pipelines(ctx context.Context, pid interface{}, opts *PipelineOptions) iter.Seq2[*Pipeline, error] {
+	id, err := pathID(pid)
+	if err != nil {
+		return fail[Pipeline](err)
+	}
+	return list[Pipeline](ctx, c, "projects/:id/pipelines", "projects/"+id+"/pipelines", opts.values())
+}
+
+// Pipeline ...
+func (c *Client) Pipeline(ctx context.Context, pid interface{}, pipelineID int) (*Pipeline, error) {
+	id, err := pathID(pid)
+	if err != nil {
+		return nil, err
+	}
+	pipeline := &Pipeline{}
+	_, err = c.get(ctx, "projects/:id/pipelines/:pipeline_id", "projects/"+id+"/pipelines/"+strconv.Itoa(pipelineID), pipeline)
+	return pipeline, err
+}
+
+// Collect 读取迭代器的全部结果，遇到错误时返回已读取的结果及错误
+func Collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// list 逐页请求，优先按 X-Next-Page 翻页，没有时(如 keyset 分页)按 Link 响应头翻页
+func list[T any](ctx context.Context, c *Client, route, path string, query url.Values) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		next := path + "?" + query.Encode()
+		for next != "" {
+			var items []*T
+			header, err := c.get(ctx, route, next, &items)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			next = nextPage(next, header)
+		}
+	}
+}
+
+// fail ...
+func fail[T any](err error) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		yield(nil, err)
+	}
+}
+
+// nextPage 下一页的相对地址，没有下一页时返回空
+// Link 中为 GitLab external_url 的完整地址，可能与客户端配置的地址不同，只取其查询参数
+func nextPage(current string, header http.Header) string {
+	u, err := url.Parse(current)
+	if err != nil {
+		return ""
+	}
+	if page := header.Get("X-Next-Page"); page != "" {
+		query := u.Query()
+		query.Set("page", page)
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+	for _, link := range strings.Split(header.Get("Link"), ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		next, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return ""
+		}
+		u.RawQuery = next.RawQuery
+		return u.String()
+	}
+	return ""
+}
+
+// get 请求并解码响应，返回响应头；429响应等待限流重置后重试
+// route 为路由模板，用于 xhttp.Instrument 指标的 route 标签
+func (c *Client) get(ctx context.Context, route, path string, out interface{}) (http.Header, error) {
+	ctx = xhttp.WithRoute(ctx, route)
+	for attempt := 1; ; attempt++ {
+		if err := c.limit.wait(ctx); err != nil {
+			return nil, err
+		}
+		r, err := c.client.NewRequest(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		r.Header.Set("Accept", "application/json")
+
+		resp, err := c.client.Do(r)
+		if err != nil {
+			return nil, err
+		}
+		c.limit.update(resp)
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < RateLimitAttempts {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			continue
+		}
+
+		err = xhttp.CheckResponse(resp)
+		if err == nil {
+			err = json.NewDecoder(resp.Body).Decode(out)
+		}
+		resp.Body.Close()
+		return resp.Header, err
+	}
+}
+
+// rateLimit 根据 RateLimit-Remaining、RateLimit-Reset 及 Retry-After 响应头，额度用尽时等待重置后再请求
+type rateLimit struct {
+	mutex     sync.Mutex
+	remaining int // -1 为未知
+	reset     time.Time
+}
+
+// wait ...
+func (l *rateLimit) wait(ctx context.Context) error {
+	l.mutex.Lock()
+	var wait time.Duration
+	if l.remaining == 0 {
+		wait = time.Until(l.reset)
+	}
+	l.mutex.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// update ...
+func (l *rateLimit) update(resp *http.Response) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if remaining, err := strconv.Atoi(resp.Header.Get("RateLimit-Remaining")); err == nil {
+		l.remaining = remaining
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("RateLimit-Reset"), 10, 64); err == nil {
+		l.reset = time.Unix(reset, 0)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	l.remaining = 0
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		l.reset = time.Now().Add(time.Duration(seconds) * time.Second)
+	} else if !l.reset.After(time.Now()) {
+		l.reset = time.Now().Add(time.Second)
+	}
+}
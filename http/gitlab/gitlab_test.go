@@ -0,0 +1,169 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	xhttp "github.com/chenpeicheng3804/go-utils/http"
+)
+
+// fakeGitlab 模拟 GitLab API：项目按 X-Next-Page 分页，群组按 Link(keyset) 分页
+func fakeGitlab(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	write := func(w http.ResponseWriter, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("/api/v4/projects", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "token" {
+			http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		var projects []Project
+		for id := (page-1)*perPage + 1; id <= page*perPage && id <= 250; id++ {
+			projects = append(projects, Project{ID: id, PathWithNamespace: fmt.Sprintf("group/p%d", id)})
+		}
+		if page*perPage < 250 {
+			w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		} else {
+			w.Header().Set("X-Next-Page", "")
+		}
+		write(w, projects)
+	})
+	mux.HandleFunc("/api/v4/groups", func(w http.ResponseWriter, r *http.Request) {
+		after, _ := strconv.Atoi(r.URL.Query().Get("id_after"))
+		groups := []Group{{ID: after + 1}, {ID: after + 2}}
+		if after < 4 {
+			// Link 为 external_url 地址，与请求地址不同
+			w.Header().Set("Link", fmt.Sprintf(`<https://gitlab.example.com/api/v4/groups?id_after=%d&pagination=keyset&per_page=2>; rel="next"`, after+2))
+		}
+		write(w, groups)
+	})
+	mux.HandleFunc("/api/v4/projects/group%2Fapp/members/all", func(w http.ResponseWriter, r *http.Request) {
+		write(w, []Member{{ID: 1, Username: "root", AccessLevel: OwnerAccess}})
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipelines", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("status") != "failed" {
+			t.Errorf("expected status filter, got %s", r.URL.RawQuery)
+		}
+		write(w, []Pipeline{{ID: 10, ProjectID: 1, Status: "failed", Ref: "main"}})
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipelines/10", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":10,"iid":3,"project_id":1,"status":"success","created_at":"2024-05-01T08:00:00.000Z"}`))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// TestPagination ...
+func TestPagination(t *testing.T) {
+	ts := fakeGitlab(t)
+	c, err := NewClient(ts.URL+"/", "token")
+	if err != nil {
+		t.Fatalf("new client fail, err: %v", err)
+	}
+	ctx := context.Background()
+
+	projects, err := Collect(c.Projects(ctx, &ProjectOptions{ListOptions: ListOptions{PerPage: 100}}))
+	if err != nil || len(projects) != 250 || projects[249].ID != 250 {
+		t.Fatalf("expected 250 projects, got %d err: %v", len(projects), err)
+	}
+	// 提前退出不再请求后续页
+	n := 0
+	for project, err := range c.Projects(ctx, nil) {
+		if err != nil || project.ID != n+1 {
+			t.Fatalf("unexpected project %+v err: %v", project, err)
+		}
+		if n++; n == 3 {
+			break
+		}
+	}
+
+	groups, err := Collect(c.Groups(ctx, &GroupOptions{ListOptions: ListOptions{PerPage: 2}}))
+	if err != nil || len(groups) != 6 || groups[5].ID != 6 {
+		t.Fatalf("expected 6 groups, got %d err: %v", len(groups), err)
+	}
+
+	members, err := Collect(c.ProjectMembers(ctx, "group/app", &MemberOptions{Inherited: true}))
+	if err != nil || len(members) != 1 || members[0].AccessLevel != OwnerAccess {
+		t.Fatalf("unexpected members %+v err: %v", members, err)
+	}
+	pipelines, err := Collect(c.Pipelines(ctx, 1, &PipelineOptions{Status: "failed"}))
+	if err != nil || len(pipelines) != 1 || pipelines[0].Ref != "main" {
+		t.Fatalf("unexpected pipelines %+v err: %v", pipelines, err)
+	}
+	pipeline, err := c.Pipeline(ctx, 1, 10)
+	if err != nil || pipeline.IID != 3 || pipeline.CreatedAt == nil || pipeline.CreatedAt.Year() != 2024 {
+		t.Fatalf("unexpected pipeline %+v err: %v", pipeline, err)
+	}
+	if _, err = Collect(c.Pipelines(ctx, 1.5, nil)); err == nil {
+		t.Fatalf("expected invalid id error")
+	}
+
+	// 认证失败返回 *xhttp.HTTPError
+	bad, _ := NewClient(ts.URL+"/api/v4", "bad")
+	var httpErr *xhttp.HTTPError
+	if _, err = Collect(bad.Projects(ctx, nil)); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %v", err)
+	}
+}
+
+// TestRateLimit ...
+func TestRateLimit(t *testing.T) {
+	var hits int32
+	var resetAt atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&hits, 1) {
+		case 1:
+			// 额度用尽，下次请求需等待重置
+			reset := time.Now().Add(time.Second).Unix()
+			resetAt.Store(reset)
+			w.Header().Set("RateLimit-Remaining", "0")
+			w.Header().Set("RateLimit-Reset", strconv.FormatInt(reset, 10))
+			w.Write([]byte(`{"id":1}`))
+		case 2:
+			if time.Now().Unix() < resetAt.Load() {
+				t.Errorf("expected request after reset")
+			}
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Retry later", http.StatusTooManyRequests)
+		default:
+			w.Header().Set("RateLimit-Remaining", "99")
+			w.Write([]byte(`{"id":1}`))
+		}
+	}))
+	defer ts.Close()
+	c, _ := NewClient(ts.URL, "token")
+	ctx := context.Background()
+
+	if _, err := c.Project(ctx, 1); err != nil {
+		t.Fatalf("get project fail, err: %v", err)
+	}
+	start := time.Now()
+	if project, err := c.Project(ctx, 1); err != nil || project.ID != 1 || atomic.LoadInt32(&hits) != 3 || time.Since(start) < time.Second {
+		t.Fatalf("expected retry after 429, got %d hits in %v err: %v", hits, time.Since(start), err)
+	}
+
+	// 等待限流期间 ctx 取消
+	c.limit.remaining, c.limit.reset = 0, time.Now().Add(time.Minute)
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.Project(timeout, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package gitlab
+
+import (
+	"time"
+)
+
+// AccessLevel 成员权限
+type AccessLevel int
+
+const (
+	NoAccess         AccessLevel = 0
+	MinimalAccess    AccessLevel = 5
+	GuestAccess      AccessLevel = 10
+	ReporterAccess   AccessLevel = 20
+	DeveloperAccess  AccessLevel = 30
+	MaintainerAccess AccessLevel = 40
+	OwnerAccess      AccessLevel = 50
+)
+
+// Namespace 项目所属的用户或群组
+type Namespace struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Kind     string `json:"kind"` // user、group
+	FullPath string `json:"full_path"`
+	ParentID int    `json:"parent_id"`
+	WebURL   string `json:"web_url"`
+}
+
+// Project ...
+type Project struct {
+	ID                int        `json:"id"`
+	Name              string     `json:"name"`
+	NameWithNamespace string     `json:"name_with_namespace"`
+	Path              string     `json:"path"`
+	PathWithNamespace string     `json:"path_with_namespace"`
+	Description       string     `json:"description"`
+	DefaultBranch     string     `json:"default_branch"`
+	Visibility        string     `json:"visibility"`
+	Archived          bool       `json:"archived"`
+	WebURL            string     `json:"web_url"`
+	SSHURLToRepo      string     `json:"ssh_url_to_repo"`
+	HTTPURLToRepo     string     `json:"http_url_to_repo"`
+	Topics            []string   `json:"topics"`
+	Namespace         *Namespace `json:"namespace"`
+	CreatedAt         *time.Time `json:"created_at"`
+	LastActivityAt    *time.Time `json:"last_activity_at"`
+}
+
+// Group ...
+type Group struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Path        string     `json:"path"`
+	FullName    string     `json:"full_name"`
+	FullPath    string     `json:"full_path"`
+	Description string     `json:"description"`
+	Visibility  string     `json:"visibility"`
+	ParentID    int        `json:"parent_id"`
+	WebURL      string     `json:"web_url"`
+	CreatedAt   *time.Time `json:"created_at"`
+}
+
+// Member 项目或群组成员
+type Member struct {
+	ID          int         `json:"id"`
+	Username    string      `json:"username"`
+	Name        string      `json:"name"`
+	State       string      `json:"state"`
+	AccessLevel AccessLevel `json:"access_level"`
+	ExpiresAt   string      `json:"expires_at"` // 日期，如 2024-12-31
+	WebURL      string      `json:"web_url"`
+	CreatedAt   *time.Time  `json:"created_at"`
+}
+
+// Pipeline ...
+type Pipeline struct {
+	ID        int        `json:"id"`
+	IID       int        `json:"iid"`
+	ProjectID int        `json:"project_id"`
+	Status    string     `json:"status"`
+	Source    string     `json:"source"`
+	Ref       string     `json:"ref"`
+	SHA       string     `json:"sha"`
+	WebURL    string     `json:"web_url"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
@@ -0,0 +1,239 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
+	"github.com/chenpeicheng3804/go-utils/util/log"
+)
+
+// routeKey ...
+type routeKey struct{}
+
+// WithRoute 标记请求的路由模板，如 "projects/:id/pipelines"，用作指标的 route 标签以控制基数
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// RouteFromContext ...
+func RouteFromContext(ctx context.Context) string {
+	route, _ := ctx.Value(routeKey{}).(string)
+	return route
+}
+
+// TransportMetrics 出站请求的prometheus指标，实现 prometheus.Collector，需自行注册
+//
+//	metrics := http.NewTransportMetrics("app", nil)
+//	prometheus.MustRegister(metrics)
+//	client, _ := http.NewClient(http.WithTransportMiddleware(http.Instrument(http.InstrumentOptions{Metrics: metrics})))
+type TransportMetrics struct {
+	route    func(r *http.Request) string
+	duration *prometheus.HistogramVec
+	size     *prometheus.HistogramVec
+}
+
+// NewTransportMetrics route 将请求映射为指标的 route 标签，nil 时使用 WithRoute 标记的路由模板，未标记为 "other"
+func NewTransportMetrics(namespace string, route func(r *http.Request) string) *TransportMetrics {
+	if route == nil {
+		route = func(r *http.Request) string {
+			if route := RouteFromContext(r.Context()); route != "" {
+				return route
+			}
+			return "other"
+		}
+	}
+
+	labels := []string{"host", "method", "route", "code"}
+	return &TransportMetrics{
+		route: route,
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "request_duration_seconds",
+			Help:      "Time from sending an outbound request until its response body is read or closed.",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+		}, labels),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "response_size_bytes",
+			Help:      "Bytes of outbound response bodies read by the caller.",
+			Buckets:   prometheus.ExponentialBuckets(128, 4, 8),
+		}, labels),
+	}
+}
+
+// Describe ...
+func (m *TransportMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.duration.Describe(ch)
+	m.size.Describe(ch)
+}
+
+// Collect ...
+func (m *TransportMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.duration.Collect(ch)
+	m.size.Collect(ch)
+}
+
+// observe ...
+func (m *TransportMetrics) observe(r *http.Request, code string, latency time.Duration, bytes int64) {
+	if m == nil {
+		return
+	}
+	labels := []string{r.URL.Host, r.Method, m.route(r), code}
+	m.duration.WithLabelValues(labels...).Observe(latency.Seconds())
+	m.size.WithLabelValues(labels...).Observe(float64(bytes))
+}
+
+// InstrumentOptions ...
+type InstrumentOptions struct {
+	// Logger nil 时使用 util/log 的全局 Logger
+	Logger *zerolog.Logger
+	// Metrics nil 时不记录指标
+	Metrics *TransportMetrics
+	// DisableTrace 不写入 traceparent 请求头
+	DisableTrace bool
+}
+
+// Instrument 出站请求日志、链路及指标中间件
+// 以 context 中的 traceparent(见 TraceParentMiddleware)或请求已有的 traceparent 为父调用写入新的 traceparent，都没有时新建链路
+// 响应体读完或关闭时记录方法、URL(隐藏密码及 token 等查询参数)、状态码、耗时及响应字节数
+// 放在 Retry 外层时每次调用记录一次，内层时每次尝试记录一次
+func Instrument(opts InstrumentOptions) TransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			start := time.Now()
+			var tp TraceParent
+			if !opts.DisableTrace {
+				parent, err := ParseTraceParent(r.Header.Get(TraceParentHeader))
+				if err != nil {
+					var ok bool
+					if parent, ok = TraceParentFromContext(r.Context()); !ok {
+						parent = NewTraceParent()
+					}
+				}
+				tp = parent.Child()
+				// RoundTripper 不能修改调用方的请求
+				r = r.Clone(r.Context())
+				r.Header.Set(TraceParentHeader, tp.String())
+			}
+
+			done := func(status int, bytes int64, err error) {
+				latency := time.Since(start)
+				code := "error"
+				if status > 0 {
+					code = strconv.Itoa(status)
+				}
+				opts.Metrics.observe(r, code, latency, bytes)
+
+				logger := &log.Logger
+				if opts.Logger != nil {
+					logger = opts.Logger
+				}
+				event := logger.Info()
+				if err != nil {
+					event = logger.Error().Err(err)
+				} else if status >= http.StatusInternalServerError {
+					event = logger.Warn()
+				}
+				event = event.Str("method", r.Method).Str("url", redactURL(r.URL))
+				if route := RouteFromContext(r.Context()); route != "" {
+					event = event.Str("route", route)
+				}
+				if status > 0 {
+					event = event.Int("status", status)
+				}
+				if tp.IsValid() {
+					event = event.Str("trace_id", tp.TraceIDString())
+				}
+				event.Dur("latency", latency).Int64("bytes", bytes).Msg("http request")
+			}
+
+			resp, err := next.RoundTrip(r)
+			if err != nil {
+				done(0, 0, err)
+				return resp, err
+			}
+			if resp.Body == nil || resp.Body == http.NoBody || resp.StatusCode == http.StatusSwitchingProtocols {
+				done(resp.StatusCode, 0, nil)
+				return resp, nil
+			}
+			resp.Body = &instrumentedBody{
+				ReadCloser: resp.Body,
+				done: func(bytes int64, err error) {
+					done(resp.StatusCode, bytes, err)
+				},
+			}
+			return resp, nil
+		})
+	}
+}
+
+// instrumentedBody 统计读取的字节数，读完或关闭时记录
+type instrumentedBody struct {
+	io.ReadCloser
+	bytes int64
+	once  sync.Once
+	done  func(bytes int64, err error)
+}
+
+// Read ...
+func (b *instrumentedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes += int64(n)
+	if err == io.EOF {
+		b.finish(nil)
+	} else if err != nil {
+		b.finish(err)
+	}
+	return n, err
+}
+
+// Close ...
+func (b *instrumentedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish(nil)
+	return err
+}
+
+// finish ...
+func (b *instrumentedBody) finish(err error) {
+	b.once.Do(func() {
+		b.done(b.bytes, err)
+	})
+}
+
+// secretParams 日志中隐藏值的查询参数，按小写名称匹配
+var secretParams = []string{"token", "private_token", "access_token", "refresh_token", "password", "secret", "client_secret", "api_key", "apikey", "key", "signature", "sign"}
+
+// redactURL 隐藏URL中的密码及敏感查询参数
+func redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+	query := u.Query()
+	redacted := false
+	for name := range query {
+		for _, secret := range secretParams {
+			if strings.ToLower(name) == secret {
+				query.Set(name, "xxxxx")
+				redacted = true
+			}
+		}
+	}
+	if !redacted {
+		return u.Redacted()
+	}
+	c := *u
+	c.RawQuery = query.Encode()
+	return c.Redacted()
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+)
+
+// TestInstrument ...
+func TestInstrument(t *testing.T) {
+	traceparent := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent <- r.Header.Get(TraceParentHeader)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		io.WriteString(w, strings.Repeat("x", 1000))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	metrics := NewTransportMetrics("test", nil)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics)
+	c, _ := NewClient(WithBaseURL(ts.URL), WithTransportMiddleware(Instrument(InstrumentOptions{Logger: &logger, Metrics: metrics})))
+
+	parent, _ := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := WithRoute(ContextWithTraceParent(context.Background(), parent), "projects/:id")
+	r, _ := c.NewRequest(ctx, http.MethodGet, "projects/1?private_token=secret&page=2", nil)
+	resp, err := c.Do(r)
+	if err != nil {
+		t.Fatalf("request fail, err: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if r.Header.Get(TraceParentHeader) != "" {
+		t.Fatalf("expected caller request not modified")
+	}
+
+	// 延续 context 中的链路
+	tp, err := ParseTraceParent(<-traceparent)
+	if err != nil || tp.TraceID != parent.TraceID || tp.SpanID == parent.SpanID {
+		t.Fatalf("expected child of parent trace, got %s err: %v", tp, err)
+	}
+	var entry map[string]interface{}
+	if err = json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("unexpected log %q, err: %v", buf.String(), err)
+	}
+	if entry["level"] != "info" || entry["method"] != "GET" || entry["status"] != float64(200) || entry["bytes"] != float64(1000) ||
+		entry["route"] != "projects/:id" || entry["trace_id"] != parent.TraceIDString() || entry["latency"] == nil {
+		t.Fatalf("unexpected log %v", entry)
+	}
+	if url := entry["url"].(string); strings.Contains(url, "secret") || !strings.Contains(url, "page=2") {
+		t.Fatalf("expected token redacted, got %s", url)
+	}
+
+	// 没有父调用时新建链路，5xx 记录为 warn
+	buf.Reset()
+	if resp, err = c.Get(context.Background(), "fail"); err != nil {
+		t.Fatalf("request fail, err: %v", err)
+	}
+	resp.Body.Close()
+	if tp, err = ParseTraceParent(<-traceparent); err != nil || tp.TraceID == parent.TraceID {
+		t.Fatalf("expected new trace, got %s err: %v", tp, err)
+	}
+	if !strings.Contains(buf.String(), `"level":"warn"`) || !strings.Contains(buf.String(), `"status":502`) {
+		t.Fatalf("unexpected log %s", buf.String())
+	}
+
+	// 请求失败记录为 error
+	buf.Reset()
+	ts.Close()
+	if _, err = c.Get(context.Background(), "down"); err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.Contains(buf.String(), `"level":"error"`) {
+		t.Fatalf("unexpected log %s", buf.String())
+	}
+
+	host := strings.TrimPrefix(ts.URL, "http://")
+	if n := testutil.CollectAndCount(metrics, "test_http_client_request_duration_seconds"); n != 3 {
+		t.Fatalf("expected 3 series, got %d", n)
+	}
+	if count, sum := histogram(t, registry, "test_http_client_response_size_bytes", host, "GET", "projects/:id", "200"); count != 1 || sum != 1000 {
+		t.Fatalf("expected 1 observation of 1000 bytes, got %d %v", count, sum)
+	}
+	if count, _ := histogram(t, registry, "test_http_client_request_duration_seconds", host, "GET", "other", "error"); count != 1 {
+		t.Fatalf("expected 1 error observation, got %d", count)
+	}
+}
+
+// histogram 按标签值(host、method、route、code)查找直方图的样本数及总和
+func histogram(t *testing.T, registry *prometheus.Registry, name string, labels ...string) (uint64, float64) {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gather fail, err: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			values := map[string]string{}
+			for _, pair := range metric.GetLabel() {
+				values[pair.GetName()] = pair.GetValue()
+			}
+			if values["host"] == labels[0] && values["method"] == labels[1] && values["route"] == labels[2] && values["code"] == labels[3] {
+				return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+			}
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// MaxErrorBodySize HTTPError 保留的响应体最大字节数
+const MaxErrorBodySize = 4 << 10
+
+// HTTPError 非2xx响应
+type HTTPError struct {
+	Method     string
+	URL        string // 已隐藏密码及 token 等查询参数
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte // 最多 MaxErrorBodySize 字节
+	Truncated  bool   // 响应体是否被截断
+}
+
+// Error ...
+func (e *HTTPError) Error() string {
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Status)
+	}
+	suffix := ""
+	if e.Truncated {
+		suffix = "..."
+	}
+	return fmt.Sprintf("%s %s: %s: %s%s", e.Method, e.URL, e.Status, bytes.TrimSpace(e.Body), suffix)
+}
+
+// CheckResponse 2xx响应返回 nil，否则读取部分响应体并返回 *HTTPError；不关闭响应体
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	e := &HTTPError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+	}
+	if r := resp.Request; r != nil {
+		e.Method = r.Method
+		e.URL = redactURL(r.URL)
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, MaxErrorBodySize+1))
+	if len(body) > MaxErrorBodySize {
+		body, e.Truncated = body[:MaxErrorBodySize], true
+	}
+	e.Body = body
+	return e
+}
+
+// GetJSON 发送 GET 请求并将响应解码为 T，非2xx响应返回 *HTTPError
+// 需要认证、代理等配置时使用 Client.JSON
+func GetJSON[T any](ctx context.Context, url string) (T, error) {
+	var out T
+	err := defaultClient.JSON(ctx, http.MethodGet, url, nil, &out)
+	return out, err
+}
+
+// PostJSON 以JSON编码 in 发送 POST 请求并将响应解码为 Resp，非2xx响应返回 *HTTPError
+func PostJSON[Req, Resp any](ctx context.Context, url string, in Req) (Resp, error) {
+	var out Resp
+	err := defaultClient.JSON(ctx, http.MethodPost, url, in, &out)
+	return out, err
+}
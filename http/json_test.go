@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestJSON ...
+func TestJSON(t *testing.T) {
+	type project struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/project":
+			io.WriteString(w, `{"id":1,"name":"app"}`)
+		case "/echo":
+			w.WriteHeader(http.StatusCreated)
+			io.Copy(w, r.Body)
+		case "/large":
+			w.Header().Set("X-Request-Id", "abc")
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, strings.Repeat("x", MaxErrorBodySize*2))
+		case "/redirect":
+			http.Redirect(w, r, "/project", http.StatusFound)
+		default:
+			http.Error(w, `{"message":"404 Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	ctx := context.Background()
+
+	p, err := GetJSON[project](ctx, ts.URL+"/project")
+	if err != nil || p.ID != 1 || p.Name != "app" {
+		t.Fatalf("unexpected project %+v, err: %v", p, err)
+	}
+	created, err := PostJSON[project, *project](ctx, ts.URL+"/echo", project{ID: 2, Name: "lib"})
+	if err != nil || created == nil || created.ID != 2 {
+		t.Fatalf("unexpected echo %+v, err: %v", created, err)
+	}
+
+	var httpErr *HTTPError
+	if _, err = GetJSON[project](ctx, ts.URL+"/missing?private_token=secret"); !errors.As(err, &httpErr) {
+		t.Fatalf("expected *HTTPError, got %v", err)
+	}
+	if httpErr.StatusCode != http.StatusNotFound || httpErr.Method != http.MethodGet || httpErr.Truncated ||
+		!strings.Contains(string(httpErr.Body), "404 Not Found") || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Fatalf("unexpected error %+v", httpErr)
+	}
+	if strings.Contains(err.Error(), "secret") || strings.Contains(httpErr.URL, "secret") {
+		t.Fatalf("expected token redacted, got %s", err)
+	}
+	if _, err = GetJSON[project](ctx, ts.URL+"/large"); !errors.As(err, &httpErr) {
+		t.Fatalf("expected *HTTPError, got %v", err)
+	}
+	if httpErr.StatusCode != http.StatusBadGateway || !httpErr.Truncated || len(httpErr.Body) != MaxErrorBodySize || httpErr.Header.Get("X-Request-Id") != "abc" {
+		t.Fatalf("unexpected error status %d, truncated %v, body %d", httpErr.StatusCode, httpErr.Truncated, len(httpErr.Body))
+	}
+
+	// 旧函数不检查状态码，保留原有行为
+	body, err := HttpClientGet(ts.URL+"/missing", "", "")
+	if err != nil || !strings.Contains(string(body), "404 Not Found") {
+		t.Fatalf("expected error body without error, got %q err: %v", body, err)
+	}
+
+	// HttpClient 返回的响应体可读且不跟随重定向
+	resp, err := HttpClient(http.MethodGet, ts.URL+"/redirect", nil)
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected 302, got %v err: %v", resp, err)
+	}
+	defer resp.Body.Close()
+	if data, err := io.ReadAll(resp.Body); err != nil || !strings.Contains(string(data), "Found") {
+		t.Fatalf("expected readable body, got %q err: %v", data, err)
+	}
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	redisson "github.com/chenpeicheng3804/go-utils/redis"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitKeyFunc 限流维度，返回空字符串时不限流
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// KeyByClientIP 按客户端IP限流
+func KeyByClientIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByRoute 按路由限流，未匹配路由的请求不限流
+func KeyByRoute(c *gin.Context) string {
+	if c.FullPath() == "" {
+		return ""
+	}
+	return "route:" + c.Request.Method + ":" + c.FullPath()
+}
+
+// KeyByRouteAndClientIP 按路由+客户端IP限流
+func KeyByRouteAndClientIP(c *gin.Context) string {
+	if c.FullPath() == "" {
+		return ""
+	}
+	return "route:" + c.Request.Method + ":" + c.FullPath() + ":ip:" + c.ClientIP()
+}
+
+// RateLimit 基于redis的限流中间件
+// 写入 X-RateLimit-Limit、X-RateLimit-Remaining、X-RateLimit-Reset 响应头，超出额度时返回 429 及 Retry-After
+// redis 不可用时放行请求；limit 无效时 panic，避免配置错误导致限流静默失效
+func RateLimit(limiter *redisson.RRateLimiter, limit redisson.Limit, keyFunc RateLimitKeyFunc) gin.HandlerFunc {
+	if err := limit.Validate(); err != nil {
+		panic(err)
+	}
+
+	return func(c *gin.Context) {
+		key := keyFunc(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		res, err := limiter.Allow(c.Request.Context(), key, limit)
+		if errors.Is(err, redisson.ErrLimitExceeded) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"msg": "too many requests"})
+			return
+		}
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(limit.Rate, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(res.Remaining, 10))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(ceilSeconds(res.ResetAfter), 10))
+
+		if !res.Allowed {
+			if res.RetryAfter > 0 {
+				c.Header("Retry-After", strconv.FormatInt(ceilSeconds(res.RetryAfter), 10))
+			}
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"msg": "too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ceilSeconds 向上取整到秒
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
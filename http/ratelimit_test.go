@@ -0,0 +1,69 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	redisson "github.com/chenpeicheng3804/go-utils/redis"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"github.com/gin-gonic/gin"
+)
+
+// TestRateLimit ...
+func TestRateLimit(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	limiter := redisson.NewGCRALimiter(rdb)
+	e.Use(RateLimit(limiter, redisson.Limit{Rate: 2, Period: time.Minute}, KeyByClientIP))
+	e.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+
+	do := func(ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = ip + ":1234"
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := do("10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+	}
+	w := do("10.0.0.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("expected 429 with retry after, got %d %v", w.Code, w.Header())
+	}
+
+	// 其他IP不受影响
+	if w = do("10.0.0.2"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for other ip, got %d", w.Code)
+	}
+
+	// redis 不可用时放行
+	s.Close()
+	if w = do("10.0.0.1"); w.Code != http.StatusOK {
+		t.Fatalf("expected fail open, got %d", w.Code)
+	}
+}
+
+// TestRateLimitInvalidLimit ...
+func TestRateLimitInvalidLimit(t *testing.T) {
+	limiter := redisson.NewGCRALimiter(redistest.Run(t).Client())
+	for _, limit := range []redisson.Limit{{}, {Rate: 0, Period: time.Minute}, {Rate: 2}} {
+		func() {
+			defer func() {
+				if err, _ := recover().(error); !errors.Is(err, redisson.ErrInvalidLimit) {
+					t.Errorf("expected panic with ErrInvalidLimit for %+v, got %v", limit, err)
+				}
+			}()
+			RateLimit(limiter, limit, KeyByClientIP)
+		}()
+	}
+}
@@ -0,0 +1,170 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// RetryMaxAttempts 默认最多请求次数(含首次)
+	RetryMaxAttempts = 3
+	// RetryBaseDelay 默认首次重试等待时间
+	RetryBaseDelay = 100 * time.Millisecond
+	// RetryMaxDelay 默认单次重试最长等待时间
+	RetryMaxDelay = 5 * time.Second
+	// RetryMaxRetryAfter 默认接受的 Retry-After 上限，超过时不再重试直接返回响应
+	RetryMaxRetryAfter = 30 * time.Second
+)
+
+// RetryPolicy 重试策略，零值字段使用默认值
+type RetryPolicy struct {
+	// MaxAttempts 最多请求次数(含首次)
+	MaxAttempts int
+	// BaseDelay 首次重试等待时间，之后每次翻倍，并叠加随机抖动
+	BaseDelay time.Duration
+	// MaxDelay 单次重试最长等待时间
+	MaxDelay time.Duration
+	// MaxRetryAfter 响应的 Retry-After 超过该值时不再重试
+	MaxRetryAfter time.Duration
+	// RetryStatus 需要重试的状态码，默认 429、500、502、503、504
+	RetryStatus []int
+}
+
+// retryableKey ...
+type retryableKey struct{}
+
+// AllowRetry 标记请求可安全重试，用于非幂等方法(POST、PATCH)
+// 也可为请求设置 Idempotency-Key 请求头，由服务端去重
+func AllowRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryableKey{}, true)
+}
+
+// idempotent GET、HEAD、OPTIONS、TRACE、PUT、DELETE 为幂等方法，其余方法需调用方允许
+func idempotent(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	if r.Header.Get("Idempotency-Key") != "" {
+		return true
+	}
+	allowed, _ := r.Context().Value(retryableKey{}).(bool)
+	return allowed
+}
+
+// Retry 失败重试中间件，网络错误及 RetryStatus 状态码按指数退避加随机抖动重试，响应带 Retry-After 时按其等待
+// 请求体须可重放(bytes.Reader、strings.Reader、bytes.Buffer 等，或设置了 GetBody)，否则不重试
+func Retry(policy RetryPolicy) TransportMiddleware {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = RetryMaxAttempts
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = RetryBaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = RetryMaxDelay
+	}
+	if policy.MaxRetryAfter <= 0 {
+		policy.MaxRetryAfter = RetryMaxRetryAfter
+	}
+	if policy.RetryStatus == nil {
+		policy.RetryStatus = []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if !idempotent(r) || (r.Body != nil && r.Body != http.NoBody && r.GetBody == nil) {
+				return next.RoundTrip(r)
+			}
+
+			req := r
+			for attempt := 1; ; attempt++ {
+				resp, err := next.RoundTrip(req)
+				if attempt >= policy.MaxAttempts || !policy.retryable(r, resp, err) {
+					return resp, err
+				}
+
+				wait := policy.backoff(attempt)
+				if resp != nil {
+					if after, ok := retryAfter(resp); ok {
+						if after > policy.MaxRetryAfter {
+							return resp, err
+						}
+						wait = after
+					}
+					// 读完响应体以复用连接
+					io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+					resp.Body.Close()
+				}
+
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-r.Context().Done():
+					timer.Stop()
+					return nil, r.Context().Err()
+				}
+
+				// 每次重试使用新的请求及请求体
+				req = r.Clone(r.Context())
+				if r.GetBody != nil {
+					if req.Body, err = r.GetBody(); err != nil {
+						return nil, err
+					}
+				}
+			}
+		})
+	}
+}
+
+// retryable ...
+func (policy RetryPolicy) retryable(r *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		// ctx 取消、超时及熔断不重试
+		return r.Context().Err() == nil && !errors.Is(err, ErrCircuitOpen)
+	}
+	for _, status := range policy.RetryStatus {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff 第 attempt 次失败后的等待时间，在 [d/2, d) 内随机，d 为指数退避时间
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	d := policy.BaseDelay << (attempt - 1)
+	if d > policy.MaxDelay || d <= 0 {
+		d = policy.MaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter 解析 Retry-After 响应头，支持秒数及HTTP日期
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
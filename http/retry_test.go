@@ -0,0 +1,114 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRetry ...
+func TestRetry(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/flaky":
+			if n < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write(body)
+		case "/after":
+			if n == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/later":
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/bad":
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient(WithBaseURL(ts.URL), WithTransportMiddleware(Retry(RetryPolicy{BaseDelay: time.Millisecond})))
+	ctx := context.Background()
+	do := func(ctx context.Context, method, path string, body io.Reader) (int, string, int32) {
+		atomic.StoreInt32(&hits, 0)
+		r, _ := c.NewRequest(ctx, method, path, body)
+		resp, err := c.Do(r)
+		if err != nil {
+			return 0, err.Error(), atomic.LoadInt32(&hits)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data), atomic.LoadInt32(&hits)
+	}
+
+	if status, _, n := do(ctx, http.MethodGet, "flaky", nil); status != http.StatusOK || n != 3 {
+		t.Fatalf("expected success after 3 attempts, got %d after %d", status, n)
+	}
+	if status, _, n := do(ctx, http.MethodGet, "down", nil); status != http.StatusInternalServerError || n != RetryMaxAttempts {
+		t.Fatalf("expected %d attempts, got %d status %d", RetryMaxAttempts, n, status)
+	}
+	if _, _, n := do(ctx, http.MethodGet, "bad", nil); n != 1 {
+		t.Fatalf("expected 4xx not retried, got %d attempts", n)
+	}
+
+	// Retry-After
+	start := time.Now()
+	if status, _, n := do(ctx, http.MethodGet, "after", nil); status != http.StatusOK || n != 2 || time.Since(start) < time.Second {
+		t.Fatalf("expected retry after 1s, got %d after %d in %v", status, n, time.Since(start))
+	}
+	if status, _, n := do(ctx, http.MethodGet, "later", nil); status != http.StatusTooManyRequests || n != 1 {
+		t.Fatalf("expected long Retry-After not retried, got %d after %d", status, n)
+	}
+
+	// 非幂等方法需调用方允许，请求体每次重放
+	if _, _, n := do(ctx, http.MethodPost, "flaky", strings.NewReader("payload")); n != 1 {
+		t.Fatalf("expected POST not retried, got %d attempts", n)
+	}
+	if status, body, n := do(AllowRetry(ctx), http.MethodPost, "flaky", strings.NewReader("payload")); status != http.StatusOK || body != "payload" || n != 3 {
+		t.Fatalf("expected POST retried with body, got %d %q after %d", status, body, n)
+	}
+	r, _ := c.NewRequest(ctx, http.MethodPost, "flaky", strings.NewReader("payload"))
+	r.Header.Set("Idempotency-Key", "key")
+	atomic.StoreInt32(&hits, 0)
+	if resp, err := c.Do(r); err != nil || resp.StatusCode != http.StatusOK || atomic.LoadInt32(&hits) != 3 {
+		t.Fatalf("expected POST with Idempotency-Key retried, got %v err: %v", resp, err)
+	}
+	// 请求体不可重放
+	if _, _, n := do(AllowRetry(ctx), http.MethodPost, "flaky", io.NopCloser(strings.NewReader("payload"))); n != 1 {
+		t.Fatalf("expected unreplayable body not retried, got %d attempts", n)
+	}
+
+	// 等待重试期间 ctx 取消
+	slow, _ := NewClient(WithBaseURL(ts.URL), WithTransportMiddleware(Retry(RetryPolicy{BaseDelay: time.Second})))
+	timeout, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if _, err := slow.Get(timeout, "down"); err == nil || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("expected canceled while waiting, err: %v after %v", err, time.Since(start))
+	}
+}
+
+// TestRetryBackoff ...
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt, max := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 10: time.Second, 100: time.Second} {
+		for i := 0; i < 100; i++ {
+			if d := policy.backoff(attempt); d < max/2 || d > max {
+				t.Fatalf("attempt %d: expected backoff in [%v, %v], got %v", attempt, max/2, max, d)
+			}
+		}
+	}
+}
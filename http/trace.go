@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TraceParentHeader W3C Trace Context 请求头
+const TraceParentHeader = "traceparent"
+
+// TraceParent W3C Trace Context 的 traceparent，格式为 00-{trace-id}-{parent-id}-{flags}
+type TraceParent struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte // 01 为采样
+}
+
+// NewTraceParent 新建链路，默认采样
+func NewTraceParent() TraceParent {
+	tp := TraceParent{Flags: 1}
+	rand.Read(tp.TraceID[:])
+	rand.Read(tp.SpanID[:])
+	return tp
+}
+
+// ParseTraceParent 解析 traceparent 请求头，兼容更高版本的扩展字段
+func ParseTraceParent(s string) (TraceParent, error) {
+	var tp TraceParent
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return tp, errors.New("invalid traceparent")
+	}
+	version, err := hex.DecodeString(s[:2])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(s) != 55) || (len(s) > 55 && s[55] != '-') {
+		return tp, errors.New("invalid traceparent version")
+	}
+	var flags [1]byte
+	if _, err = hex.Decode(tp.TraceID[:], []byte(s[3:35])); err != nil {
+		return tp, errors.New("invalid traceparent trace-id")
+	}
+	if _, err = hex.Decode(tp.SpanID[:], []byte(s[36:52])); err != nil {
+		return tp, errors.New("invalid traceparent parent-id")
+	}
+	if _, err = hex.Decode(flags[:], []byte(s[53:55])); err != nil {
+		return tp, errors.New("invalid traceparent flags")
+	}
+	tp.Flags = flags[0]
+	if !tp.IsValid() {
+		return tp, errors.New("invalid traceparent: all zero id")
+	}
+	return tp, nil
+}
+
+// IsValid trace-id 及 parent-id 不能全为0
+func (tp TraceParent) IsValid() bool {
+	return tp.TraceID != [16]byte{} && tp.SpanID != [8]byte{}
+}
+
+// Child 同一链路下的子调用，生成新的 parent-id
+func (tp TraceParent) Child() TraceParent {
+	rand.Read(tp.SpanID[:])
+	return tp
+}
+
+// TraceIDString ...
+func (tp TraceParent) TraceIDString() string {
+	return hex.EncodeToString(tp.TraceID[:])
+}
+
+// String ...
+func (tp TraceParent) String() string {
+	return "00-" + hex.EncodeToString(tp.TraceID[:]) + "-" + hex.EncodeToString(tp.SpanID[:]) + "-" + hex.EncodeToString([]byte{tp.Flags})
+}
+
+// traceParentKey ...
+type traceParentKey struct{}
+
+// ContextWithTraceParent 保存当前调用的 traceparent，出站请求以其为父调用
+func ContextWithTraceParent(ctx context.Context, tp TraceParent) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, tp)
+}
+
+// TraceParentFromContext ...
+func TraceParentFromContext(ctx context.Context) (TraceParent, bool) {
+	tp, ok := ctx.Value(traceParentKey{}).(TraceParent)
+	return tp, ok
+}
+
+// TraceParentMiddleware 读取入站请求的 traceparent(没有或无效时新建链路)保存到请求 context，
+// 处理函数使用 c.Request.Context() 发起的出站请求通过 Instrument 中间件延续同一链路
+func TraceParentMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tp, err := ParseTraceParent(c.GetHeader(TraceParentHeader))
+		if err != nil {
+			tp = NewTraceParent()
+		}
+		c.Request = c.Request.WithContext(ContextWithTraceParent(c.Request.Context(), tp))
+		c.Next()
+	}
+}
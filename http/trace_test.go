@@ -0,0 +1,72 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestTraceParent ...
+func TestTraceParent(t *testing.T) {
+	const header = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tp, err := ParseTraceParent(header)
+	if err != nil || tp.String() != header || tp.TraceIDString() != "4bf92f3577b34da6a3ce929d0e0e4736" || tp.Flags != 1 {
+		t.Fatalf("unexpected traceparent %s, err: %v", tp, err)
+	}
+	child := tp.Child()
+	if child.TraceID != tp.TraceID || child.SpanID == tp.SpanID || child.Flags != tp.Flags {
+		t.Fatalf("expected same trace with new span, got %s", child)
+	}
+	// 更高版本可带扩展字段
+	if _, err = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Fatalf("expected future version accepted, err: %v", err)
+	}
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+	} {
+		if _, err = ParseTraceParent(invalid); err == nil {
+			t.Fatalf("expected %q invalid", invalid)
+		}
+	}
+	if tp = NewTraceParent(); !tp.IsValid() || len(tp.String()) != 55 {
+		t.Fatalf("unexpected new traceparent %s", tp)
+	}
+}
+
+// TestTraceParentMiddleware ...
+func TestTraceParentMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TraceParentMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		tp, ok := TraceParentFromContext(c.Request.Context())
+		if !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, tp.TraceIDString())
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(w, r)
+	if w.Body.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected incoming trace kept, got %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK || len(w.Body.String()) != 32 || strings.Trim(w.Body.String(), "0") == "" {
+		t.Fatalf("expected new trace, got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,378 @@
+package redisson
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// CacheKeyPrefix 缓存key前缀
+	CacheKeyPrefix = "cache:"
+	// CacheInvalidateChannel 近端缓存失效广播频道前缀
+	CacheInvalidateChannel = "CacheInvalidateChannel:"
+	// CacheNegativeTTL 默认空值缓存时间
+	CacheNegativeTTL = time.Minute
+	// CacheNearTTL 默认近端缓存最长缓存时间
+	CacheNearTTL = time.Minute
+	// CacheLoadTimeout 默认 loader 超时时间
+	CacheLoadTimeout = 10 * time.Second
+)
+
+// ErrNotFound loader 返回该错误表示数据不存在，结果会按空值缓存
+var ErrNotFound = errors.New("cache: not found")
+
+// cacheNotFound redis中空值的存储形式，json编码结果不会是空字符串
+const cacheNotFound = ""
+
+// CacheOption 缓存配置
+type CacheOption func(o *cacheOptions)
+
+// cacheOptions ...
+type cacheOptions struct {
+	jitter      float64
+	negativeTTL time.Duration
+	localSize   int
+	localTTL    time.Duration
+	loadTimeout time.Duration
+}
+
+// WithJitter ttl 随机增加 [0, ttl*ratio) 避免大量key同时过期
+func WithJitter(ratio float64) CacheOption {
+	return func(o *cacheOptions) {
+		o.jitter = ratio
+	}
+}
+
+// WithNegativeTTL 空值缓存时间，ttl<=0 时不缓存空值
+func WithNegativeTTL(ttl time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.negativeTTL = ttl
+	}
+}
+
+// WithNearCache 启用进程内LRU近端缓存，size 为最大条目数，ttl 为本地最长缓存时间，ttl<=0 时使用 CacheNearTTL
+// 通过 Set/Delete 修改数据时经redis发布订阅广播失效其他实例的近端缓存
+func WithNearCache(size int, ttl time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		if ttl <= 0 {
+			ttl = CacheNearTTL
+		}
+		o.localSize = size
+		o.localTTL = ttl
+	}
+}
+
+// WithLoadTimeout loader 超时时间，timeout<=0 时使用 CacheLoadTimeout
+func WithLoadTimeout(timeout time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.loadTimeout = timeout
+	}
+}
+
+// cacheEntry ...
+type cacheEntry[T any] struct {
+	key      string
+	value    T
+	found    bool
+	expireAt time.Time
+}
+
+// lruCache 进程内LRU缓存
+type lruCache[T any] struct {
+	mutex sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+// newLRUCache ...
+func newLRUCache[T any](size int) *lruCache[T] {
+	return &lruCache[T]{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// get ...
+func (c *lruCache[T]) get(key string) (*cacheEntry[T], bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*cacheEntry[T])
+	if time.Now().After(entry.expireAt) {
+		c.ll.Remove(e)
+		delete(c.items, key)
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return entry, true
+}
+
+// add ...
+func (c *lruCache[T]) add(entry *cacheEntry[T]) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, ok := c.items[entry.key]; ok {
+		e.Value = entry
+		c.ll.MoveToFront(e)
+		return
+	}
+	c.items[entry.key] = c.ll.PushFront(entry)
+	if c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry[T]).key)
+	}
+}
+
+// remove ...
+func (c *lruCache[T]) remove(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.Remove(e)
+		delete(c.items, key)
+	}
+}
+
+// Cache 缓存旁路(cache-aside)读写，值以json编码存储在redis
+type Cache[T any] struct {
+	rdb     redis.Cmdable
+	name    string
+	opts    cacheOptions
+	group   singleflight.Group
+	local   *lruCache[T]
+	unwatch func()
+}
+
+// NewCache name 为缓存命名空间，redis key 为 CacheKeyPrefix+name+":"+key
+func NewCache[T any](rdb redis.Cmdable, name string, opts ...CacheOption) *Cache[T] {
+	c := &Cache[T]{
+		rdb:     rdb,
+		name:    name,
+		opts:    cacheOptions{negativeTTL: CacheNegativeTTL, loadTimeout: CacheLoadTimeout},
+		unwatch: func() {},
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	if c.opts.loadTimeout <= 0 {
+		c.opts.loadTimeout = CacheLoadTimeout
+	}
+
+	if c.opts.localSize > 0 {
+		c.local = newLRUCache[T](c.opts.localSize)
+		c.watch(context.Background())
+	}
+	return c
+}
+
+// redisKey ...
+func (c *Cache[T]) redisKey(key string) string {
+	return CacheKeyPrefix + c.name + ":" + key
+}
+
+// channel ...
+func (c *Cache[T]) channel() string {
+	return CacheInvalidateChannel + c.name
+}
+
+// watch 订阅失效广播，客户端不支持订阅时近端缓存只依赖本地ttl过期
+func (c *Cache[T]) watch(ctx context.Context) {
+	ch, unsubscribe := subscribe(ctx, c.rdb, c.channel())
+	if ch == nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	var once sync.Once
+	c.unwatch = func() {
+		once.Do(func() {
+			close(stop)
+			unsubscribe()
+		})
+	}
+
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				c.local.remove(msg.Payload)
+			}
+		}
+	}()
+}
+
+// ttl 加随机抖动后的过期时间
+func (c *Cache[T]) ttl(ttl time.Duration) time.Duration {
+	if c.opts.jitter <= 0 || ttl <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Int63n(int64(float64(ttl)*c.opts.jitter)+1))
+}
+
+// remember 写入近端缓存，本地缓存时间不超过redis中的剩余时间
+func (c *Cache[T]) remember(entry *cacheEntry[T], ttl time.Duration) {
+	if c.local == nil {
+		return
+	}
+	if ttl <= 0 || ttl > c.opts.localTTL {
+		ttl = c.opts.localTTL
+	}
+	entry.expireAt = time.Now().Add(ttl)
+	c.local.add(entry)
+}
+
+// GetOrLoad 依次读取近端缓存、redis，均未命中时调用 loader 加载并回写
+// 同一实例内对同一 key 的并发加载只执行一次 loader，loader 不随调用方 ctx 取消，超时时间见 WithLoadTimeout
+// 各调用方 ctx 取消时只有自身返回
+// loader 返回 ErrNotFound 时按空值缓存，之后在空值过期前返回 ErrNotFound
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	if c.local != nil {
+		if entry, ok := c.local.get(key); ok {
+			return entry.result()
+		}
+	}
+
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		// 首个调用方取消时其他等待者仍可获得结果
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.opts.loadTimeout)
+		defer cancel()
+		return c.load(ctx, key, ttl, loader)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		return res.Val.(*cacheEntry[T]).result()
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// load ...
+func (c *Cache[T]) load(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (*cacheEntry[T], error) {
+	redisKey := c.redisKey(key)
+
+	data, err := c.rdb.Get(ctx, redisKey).Result()
+	if err == nil {
+		entry := &cacheEntry[T]{key: key, found: data != cacheNotFound}
+		if entry.found {
+			if err = json.Unmarshal([]byte(data), &entry.value); err != nil {
+				return nil, err
+			}
+		}
+		remaining, _ := c.rdb.PTTL(ctx, redisKey).Result()
+		c.remember(entry, remaining)
+		return entry, nil
+	}
+	// redis不可用时直接回源，不回写
+	available := err == redis.Nil
+
+	value, err := loader(ctx)
+	if errors.Is(err, ErrNotFound) {
+		entry := &cacheEntry[T]{key: key}
+		if c.opts.negativeTTL > 0 {
+			negativeTTL := c.ttl(c.opts.negativeTTL)
+			if available {
+				c.rdb.Set(ctx, redisKey, cacheNotFound, negativeTTL)
+			}
+			c.remember(entry, negativeTTL)
+		}
+		return entry, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &cacheEntry[T]{key: key, value: value, found: true}
+	ttl = c.ttl(ttl)
+	if available {
+		if data, err := json.Marshal(value); err == nil {
+			c.rdb.Set(ctx, redisKey, data, ttl)
+		}
+	}
+	c.remember(entry, ttl)
+	return entry, nil
+}
+
+// result ...
+func (entry *cacheEntry[T]) result() (T, error) {
+	if !entry.found {
+		var zero T
+		return zero, ErrNotFound
+	}
+	return entry.value, nil
+}
+
+// Set 写入缓存并广播失效其他实例的近端缓存
+func (c *Cache[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err = c.rdb.Set(ctx, c.redisKey(key), data, c.ttl(ttl)).Err(); err != nil {
+		return err
+	}
+	return c.invalidate(ctx, key)
+}
+
+// Delete 删除缓存并广播失效其他实例的近端缓存
+func (c *Cache[T]) Delete(ctx context.Context, keys ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// 逐个删除，集群模式下多个key可能不在同一个slot
+	for _, key := range keys {
+		if err := c.rdb.Del(ctx, c.redisKey(key)).Err(); err != nil {
+			return err
+		}
+		if err := c.invalidate(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invalidate 本实例未启用近端缓存时同样广播，其他实例可能启用
+func (c *Cache[T]) invalidate(ctx context.Context, key string) error {
+	if c.local != nil {
+		c.local.remove(key)
+	}
+	return c.rdb.Publish(ctx, c.channel(), key).Err()
+}
+
+// Close 停止订阅失效广播
+func (c *Cache[T]) Close() {
+	c.unwatch()
+}
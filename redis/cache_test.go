@@ -0,0 +1,206 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// user ...
+type user struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// TestCacheGetOrLoad ...
+func TestCacheGetOrLoad(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx := context.Background()
+
+	c := NewCache[user](rdb, "user", WithJitter(0.1), WithNegativeTTL(time.Second))
+	var loads int32
+	loader := func(ctx context.Context) (user, error) {
+		atomic.AddInt32(&loads, 1)
+		time.Sleep(20 * time.Millisecond)
+		return user{Id: 1, Name: "demo"}, nil
+	}
+
+	// 并发加载只执行一次 loader
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := c.GetOrLoad(ctx, "1", time.Minute, loader)
+			if err != nil || u.Name != "demo" {
+				t.Errorf("get or load fail, user: %+v err: %v", u, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if loads != 1 {
+		t.Fatalf("expected 1 load, got %d", loads)
+	}
+
+	// 写入redis，ttl 带抖动
+	if ttl := s.TTL(CacheKeyPrefix + "user:1"); ttl < time.Minute || ttl > time.Minute+6*time.Second {
+		t.Fatalf("unexpected ttl %v", ttl)
+	}
+	if u, _ := c.GetOrLoad(ctx, "1", time.Minute, loader); u.Id != 1 || loads != 1 {
+		t.Fatalf("expected redis hit, loads: %d", loads)
+	}
+
+	// 空值缓存
+	notFound := func(ctx context.Context) (user, error) {
+		atomic.AddInt32(&loads, 1)
+		return user{}, ErrNotFound
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetOrLoad(ctx, "2", time.Minute, notFound); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+	if loads != 2 {
+		t.Fatalf("expected not found cached, loads: %d", loads)
+	}
+
+	// loader 错误不缓存
+	failed := errors.New("db down")
+	if _, err := c.GetOrLoad(ctx, "3", time.Minute, func(ctx context.Context) (user, error) {
+		return user{}, failed
+	}); !errors.Is(err, failed) || s.Exists(CacheKeyPrefix+"user:3") {
+		t.Fatalf("expected loader error without caching, got %v", err)
+	}
+}
+
+// TestCacheNearCache ...
+func TestCacheNearCache(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx := context.Background()
+
+	// 模拟两个实例
+	a := NewCache[user](rdb, "user", WithNearCache(2, time.Minute))
+	defer a.Close()
+	b := NewCache[user](rdb, "user", WithNearCache(2, time.Minute))
+	defer b.Close()
+
+	load := func(name string) func(ctx context.Context) (user, error) {
+		return func(ctx context.Context) (user, error) {
+			return user{Id: 1, Name: name}, nil
+		}
+	}
+	if u, _ := b.GetOrLoad(ctx, "1", time.Minute, load("v1")); u.Name != "v1" {
+		t.Fatalf("unexpected user %+v", u)
+	}
+
+	// 近端缓存命中，不访问redis
+	s.FlushAll()
+	if u, _ := b.GetOrLoad(ctx, "1", time.Minute, load("v2")); u.Name != "v1" {
+		t.Fatalf("expected near cache hit, got %+v", u)
+	}
+
+	// 其他实例修改后经广播失效
+	if err := a.Set(ctx, "1", user{Id: 1, Name: "v3"}, time.Minute); err != nil {
+		t.Fatalf("set fail, err: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		u, _ := b.GetOrLoad(ctx, "1", time.Minute, load("v2"))
+		if u.Name == "v3" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected near cache invalidated, got %+v", u)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// LRU 淘汰
+	b.GetOrLoad(ctx, "2", time.Minute, load("v1"))
+	b.GetOrLoad(ctx, "3", time.Minute, load("v1"))
+	if _, ok := b.local.get("1"); ok {
+		t.Fatalf("expected least recently used entry evicted")
+	}
+
+	if err := a.Delete(ctx, "2", "3"); err != nil || s.Exists(CacheKeyPrefix+"user:2") {
+		t.Fatalf("delete fail, err: %v", err)
+	}
+}
+
+// TestCacheLoaderCancel ...
+func TestCacheLoaderCancel(t *testing.T) {
+	rdb := redistest.Run(t).Client()
+	ctx := context.Background()
+	c := NewCache[user](rdb, "user", WithLoadTimeout(500*time.Millisecond))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	loader := func(ctx context.Context) (user, error) {
+		close(started)
+		select {
+		case <-release:
+			return user{Id: 1, Name: "demo"}, nil
+		case <-ctx.Done():
+			return user{}, ctx.Err()
+		}
+	}
+
+	// 首个调用方取消不影响其他等待者
+	first, cancel := context.WithCancel(ctx)
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(first, "1", time.Minute, loader)
+		firstErr <- err
+	}()
+	<-started
+	waiter := make(chan error, 1)
+	go func() {
+		u, err := c.GetOrLoad(ctx, "1", time.Minute, loader)
+		if err == nil && u.Name != "demo" {
+			err = errors.New("unexpected user " + u.Name)
+		}
+		waiter <- err
+	}()
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	close(release)
+	if err := <-waiter; err != nil {
+		t.Fatalf("expected waiter loaded, err: %v", err)
+	}
+
+	// loader 超时
+	_, err := c.GetOrLoad(ctx, "2", time.Minute, func(ctx context.Context) (user, error) {
+		<-ctx.Done()
+		return user{}, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+// TestCacheNearCacheDefaultTTL ...
+func TestCacheNearCacheDefaultTTL(t *testing.T) {
+	s := redistest.Run(t)
+	ctx := context.Background()
+	c := NewCache[user](s.Client(), "user", WithNearCache(2, 0))
+	defer c.Close()
+
+	if c.opts.localTTL != CacheNearTTL {
+		t.Fatalf("expected default near ttl, got %v", c.opts.localTTL)
+	}
+	c.GetOrLoad(ctx, "1", time.Minute, func(ctx context.Context) (user, error) {
+		return user{Id: 1, Name: "v1"}, nil
+	})
+	if _, ok := c.local.get("1"); !ok {
+		t.Fatalf("expected near cache hit")
+	}
+}
@@ -1,7 +1,7 @@
 package redisson
 
 import (
-	"github.com/go-redis/redis"
+	"github.com/redis/go-redis/v9"
 )
 
 type Rdb struct {
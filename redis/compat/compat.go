@@ -0,0 +1,298 @@
+// Package compat 迁移期兼容层，保留基于 github.com/go-redis/redis(v6) 的旧接口
+// 现有调用方只需将导入路径改为 "github.com/chenpeicheng3804/go-utils/redis/compat" 即可继续编译，
+// 内部将 v6 客户端的配置转换为 go-redis/v9 客户端后调用 redisson 包
+//
+// Deprecated: 请迁移到 redisson 包中携带 context 的接口
+package compat
+
+import (
+	"context"
+	"fmt"
+	redisson "github.com/chenpeicheng3804/go-utils/redis"
+	"github.com/chenpeicheng3804/go-utils/util"
+	v6 "github.com/go-redis/redis"
+	"github.com/redis/go-redis/v9"
+	"net"
+	"sync"
+	"time"
+)
+
+// LockHandle 同 redisson.LockHandle
+type LockHandle = redisson.LockHandle
+
+// RLock 保留旧版方法集的分布式锁，同一 RLock 在同一协程内加锁即为锁重入
+type RLock struct {
+	rLock    *redisson.RLock
+	clientId string
+	mutex    sync.Mutex
+	// handles 按持有者标识(协程)保存加锁成功的锁句柄，UnLock 只释放当前协程最近一次加的锁
+	handles map[string][]*LockHandle
+}
+
+// Rdb 旧版客户端，Client 仍为 v6 客户端
+type Rdb struct {
+	Client *v6.Client
+	RLock  *RLock
+}
+
+// NewClient ...
+func NewClient(Addr, Password string, DB int) *Rdb {
+	return &Rdb{
+		Client: v6.NewClient(&v6.Options{
+			Addr:     Addr,
+			Password: Password,
+			DB:       DB,
+		}),
+	}
+}
+
+// NextId
+// 基于redis生成唯一id
+func (rdb *Rdb) NextId(key string) int64 {
+	return NextId(rdb.Client, key)
+}
+
+// Close 关闭 v6 客户端及其对应的 v9 客户端
+func (rdb *Rdb) Close() error {
+	Release(rdb.Client)
+	return rdb.Client.Close()
+}
+
+// NewRLock 使用 v6 客户端创建 RLock，连接失败或客户端类型不支持时返回 nil
+func NewRLock(rdb v6.Cmdable) *RLock {
+	client := Upgrade(rdb)
+	if client == nil {
+		return nil
+	}
+	rLock := wrapRLock(redisson.NewRLock(context.Background(), client))
+	if rLock == nil {
+		Release(rdb)
+	}
+	return rLock
+}
+
+// NewRLockWithClient 使用调用方持有的 v9 客户端创建 RLock，连接失败时返回 nil
+func NewRLockWithClient(rdb redis.Cmdable) *RLock {
+	return wrapRLock(redisson.NewRLock(context.Background(), rdb))
+}
+
+// wrapRLock ...
+func wrapRLock(rLock *redisson.RLock) *RLock {
+	if rLock == nil {
+		return nil
+	}
+	return &RLock{
+		rLock:    rLock,
+		clientId: redisson.NewOwnerToken(),
+	}
+}
+
+// Unwrap 返回携带 context 接口的 redisson.RLock，便于逐步迁移
+func (rLock *RLock) Unwrap() *redisson.RLock {
+	if rLock == nil {
+		return nil
+	}
+	return rLock.rLock
+}
+
+// uniqueId 同一 RLock 同一协程使用相同的持有者标识，与旧版锁重入语义一致
+func (rLock *RLock) uniqueId() string {
+	return fmt.Sprintf("%s-%d", rLock.clientId, util.GetGoroutineID())
+}
+
+// owner ...
+func (rLock *RLock) owner() *redisson.RLock {
+	return rLock.rLock.WithOwner(rLock.uniqueId())
+}
+
+// push ...
+func (rLock *RLock) push(h *LockHandle) {
+	rLock.mutex.Lock()
+	defer rLock.mutex.Unlock()
+	if rLock.handles == nil {
+		rLock.handles = make(map[string][]*LockHandle)
+	}
+	rLock.handles[h.Token()] = append(rLock.handles[h.Token()], h)
+}
+
+// pop 取出 owner 最近一次加锁成功的锁句柄，其他协程持有锁时返回 ErrNotOwner
+func (rLock *RLock) pop(owner string) (*LockHandle, error) {
+	rLock.mutex.Lock()
+	defer rLock.mutex.Unlock()
+
+	handles := rLock.handles[owner]
+	if len(handles) == 0 {
+		if len(rLock.handles) > 0 {
+			return nil, redisson.ErrNotOwner
+		}
+		return nil, redisson.ErrLockNotFound
+	}
+
+	h := handles[len(handles)-1]
+	if len(handles) == 1 {
+		delete(rLock.handles, owner)
+	} else {
+		rLock.handles[owner] = handles[:len(handles)-1]
+	}
+	return h, nil
+}
+
+// Lock 同旧版 Lock，加锁失败返回错误
+func (rLock *RLock) Lock(name string, args ...time.Duration) error {
+	if rLock == nil {
+		return redisson.ErrNotConnected
+	}
+
+	h, err := rLock.owner().Lock(context.Background(), name, args...)
+	if err != nil {
+		return err
+	}
+	rLock.push(h)
+	return nil
+}
+
+// TryLock 同旧版 TryLock，超时时间内不断尝试加锁
+func (rLock *RLock) TryLock(name string, args ...time.Duration) error {
+	if rLock == nil {
+		return redisson.ErrNotConnected
+	}
+
+	h, err := rLock.owner().TryLock(context.Background(), name, args...)
+	if err != nil {
+		return err
+	}
+	rLock.push(h)
+	return nil
+}
+
+// UnLock 同旧版 UnLock，释放当前协程最近一次加锁成功的锁，不允许释放其他协程持有的锁
+func (rLock *RLock) UnLock() error {
+	if rLock == nil {
+		return redisson.ErrNotConnected
+	}
+
+	h, err := rLock.pop(rLock.uniqueId())
+	if err != nil {
+		return err
+	}
+	return h.Unlock(context.Background())
+}
+
+// NextId 全局唯一id生成
+func (rLock *RLock) NextId(key string) int64 {
+	if rLock == nil {
+		return 0
+	}
+	return rLock.rLock.NextId(context.Background(), key)
+}
+
+// NextId 基于redis生成唯一id
+func NextId(rdb v6.Cmdable, key string) int64 {
+	client := Upgrade(rdb)
+	if client == nil {
+		return 0
+	}
+	return redisson.NextId(context.Background(), client, key)
+}
+
+// upgraded v6 客户端到 v9 客户端的映射，同一 v6 客户端只创建一次连接池，调用 Release 后移除
+var upgraded sync.Map
+
+// Upgrade 按 v6 客户端的配置创建等价的 v9 客户端，支持 *v6.Client(含哨兵模式)与 *v6.ClusterClient
+// 其他类型返回 nil；同一 v6 客户端多次调用返回同一个 v9 客户端，不再使用时需调用 Release 关闭
+func Upgrade(rdb v6.Cmdable) redis.UniversalClient {
+	if client, ok := upgraded.Load(rdb); ok {
+		return client.(redis.UniversalClient)
+	}
+
+	var client redis.UniversalClient
+	switch c := rdb.(type) {
+	case *v6.Client:
+		client = upgradeClient(c.Options())
+	case *v6.ClusterClient:
+		client = upgradeClusterClient(c.Options())
+	default:
+		return nil
+	}
+
+	if actual, loaded := upgraded.LoadOrStore(rdb, client); loaded {
+		client.Close()
+		return actual.(redis.UniversalClient)
+	}
+	return client
+}
+
+// Release 移除并关闭 Upgrade 为 v6 客户端创建的 v9 客户端，未创建过时直接返回 nil
+// 关闭后基于该 v9 客户端创建的 RLock 等不再可用
+func Release(rdb v6.Cmdable) error {
+	client, ok := upgraded.LoadAndDelete(rdb)
+	if !ok {
+		return nil
+	}
+	return client.(redis.UniversalClient).Close()
+}
+
+// upgradeClient 复用 v6 的拨号函数，TLS 及哨兵模式下的主节点发现均由其完成
+func upgradeClient(opt *v6.Options) *redis.Client {
+	dialer := opt.Dialer
+	return redis.NewClient(&redis.Options{
+		Network: opt.Network,
+		Addr:    opt.Addr,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer()
+		},
+		Password:        opt.Password,
+		DB:              opt.DB,
+		MaxRetries:      maxRetries(opt.MaxRetries),
+		MinRetryBackoff: opt.MinRetryBackoff,
+		MaxRetryBackoff: opt.MaxRetryBackoff,
+		DialTimeout:     opt.DialTimeout,
+		ReadTimeout:     timeout(opt.ReadTimeout),
+		WriteTimeout:    timeout(opt.WriteTimeout),
+		PoolSize:        opt.PoolSize,
+		MinIdleConns:    opt.MinIdleConns,
+		PoolTimeout:     opt.PoolTimeout,
+		ConnMaxIdleTime: opt.IdleTimeout,
+		ConnMaxLifetime: opt.MaxConnAge,
+	})
+}
+
+// upgradeClusterClient ...
+func upgradeClusterClient(opt *v6.ClusterOptions) *redis.ClusterClient {
+	return redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs:           opt.Addrs,
+		MaxRedirects:    opt.MaxRedirects,
+		ReadOnly:        opt.ReadOnly,
+		RouteByLatency:  opt.RouteByLatency,
+		RouteRandomly:   opt.RouteRandomly,
+		Password:        opt.Password,
+		MaxRetries:      maxRetries(opt.MaxRetries),
+		MinRetryBackoff: opt.MinRetryBackoff,
+		MaxRetryBackoff: opt.MaxRetryBackoff,
+		DialTimeout:     opt.DialTimeout,
+		ReadTimeout:     timeout(opt.ReadTimeout),
+		WriteTimeout:    timeout(opt.WriteTimeout),
+		PoolSize:        opt.PoolSize,
+		MinIdleConns:    opt.MinIdleConns,
+		PoolTimeout:     opt.PoolTimeout,
+		ConnMaxIdleTime: opt.IdleTimeout,
+		ConnMaxLifetime: opt.MaxConnAge,
+		TLSConfig:       opt.TLSConfig,
+	})
+}
+
+// maxRetries v6 中0为不重试，v9 中需为-1
+func maxRetries(n int) int {
+	if n == 0 {
+		return -1
+	}
+	return n
+}
+
+// timeout v6 配置初始化后0为不超时，v9 中需为-1
+func timeout(d time.Duration) time.Duration {
+	if d == 0 {
+		return -1
+	}
+	return d
+}
@@ -0,0 +1,124 @@
+package compat
+
+import (
+	"context"
+	"errors"
+	redisson "github.com/chenpeicheng3804/go-utils/redis"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	v6 "github.com/go-redis/redis"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestCompat ...
+func TestCompat(t *testing.T) {
+	s := redistest.Run(t, redistest.WithPassword("demo"))
+
+	rdb := NewClient(s.Addr(), "demo", 0)
+	defer rdb.Close()
+
+	// 旧接口
+	rdb.RLock = NewRLock(rdb.Client)
+	if rdb.RLock == nil {
+		t.Fatalf("new rlock fail")
+	}
+	if err := rdb.RLock.Lock("compat"); err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+	// 同一协程重入
+	if err := rdb.RLock.TryLock("compat", time.Second, 100*time.Millisecond); err != nil {
+		t.Fatalf("reentrant lock fail, err: %v", err)
+	}
+
+	// 其他协程加锁失败
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := rdb.RLock.TryLock("compat", time.Second, 100*time.Millisecond); err == nil {
+			t.Errorf("expected lock held by another goroutine")
+		}
+	}()
+	wg.Wait()
+
+	for i := 0; i < 2; i++ {
+		if err := rdb.RLock.UnLock(); err != nil {
+			t.Fatalf("unlock fail, err: %v", err)
+		}
+	}
+	if err := rdb.RLock.UnLock(); !errors.Is(err, redisson.ErrLockNotFound) {
+		t.Fatalf("expected ErrLockNotFound, got %v", err)
+	}
+	if s.Exists("compat") {
+		t.Fatalf("expected lock released")
+	}
+
+	// 其他协程不能释放当前协程持有的锁
+	if err := rdb.RLock.Lock("compat"); err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := rdb.RLock.Lock("compat:other"); err != nil {
+			t.Errorf("lock fail, err: %v", err)
+			return
+		}
+		if err := rdb.RLock.UnLock(); err != nil {
+			t.Errorf("unlock fail, err: %v", err)
+		}
+		if err := rdb.RLock.UnLock(); !errors.Is(err, redisson.ErrNotOwner) {
+			t.Errorf("expected ErrNotOwner, got %v", err)
+		}
+	}()
+	wg.Wait()
+	if !s.Exists("compat") || s.Exists("compat:other") {
+		t.Fatalf("expected only the other goroutine's lock released")
+	}
+	if err := rdb.RLock.UnLock(); err != nil {
+		t.Fatalf("unlock fail, err: %v", err)
+	}
+	if s.Exists("compat") {
+		t.Fatalf("expected lock released")
+	}
+
+	if a, b := rdb.NextId("compat"), NextId(rdb.Client, "compat"); a == 0 || b != a+1 {
+		t.Fatalf("unexpected ids %d %d", a, b)
+	}
+	if id := rdb.RLock.NextId("compat"); id == 0 {
+		t.Fatalf("unexpected id %d", id)
+	}
+
+	// 同一 v6 客户端复用同一 v9 客户端，Release 后重新创建
+	client := Upgrade(rdb.Client)
+	if client != Upgrade(rdb.Client) {
+		t.Fatalf("expected upgraded client reused")
+	}
+	if err := Release(rdb.Client); err != nil {
+		t.Fatalf("release fail, err: %v", err)
+	}
+	if client.Ping(context.Background()).Err() == nil {
+		t.Fatalf("expected released client closed")
+	}
+	if Upgrade(rdb.Client) == client {
+		t.Fatalf("expected new upgraded client after release")
+	}
+	if Upgrade(v6.NewRing(&v6.RingOptions{})) != nil {
+		t.Fatalf("expected unsupported client")
+	}
+
+	// 调用方自行持有 v9 客户端
+	if rLock := NewRLockWithClient(s.Client()); rLock == nil || rLock.Unwrap() == nil {
+		t.Fatalf("new rlock with client fail")
+	}
+
+	// 连接失败时不缓存 v9 客户端
+	bad := v6.NewClient(&v6.Options{Addr: "127.0.0.1:1"})
+	if NewRLock(bad) != nil {
+		t.Fatalf("expected nil rlock")
+	}
+	if _, ok := upgraded.Load(bad); ok {
+		t.Fatalf("expected failed client released")
+	}
+}
@@ -0,0 +1,197 @@
+package redisson
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	// DelayedPollInterval 阻塞读取就绪队列的超时时间，redis BLMOVE 最小支持1秒
+	DelayedPollInterval = time.Second
+	// DelayedMoveBatch 每次转移到就绪队列的最大数量
+	DelayedMoveBatch = 100
+)
+
+// rDelayedOfferScript ...
+var rDelayedOfferScript = redis.NewScript(`
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[3]);
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1]);
+return 1;
+`)
+
+// rDelayedTakeScript ...
+var rDelayedTakeScript = redis.NewScript(`
+-- 将到期任务原子地转移到就绪队列
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2]);
+for i = 1, #ids, 1 do
+    redis.call('ZREM', KEYS[1], ids[i]);
+    redis.call('RPUSH', KEYS[3], ids[i]);
+end;
+
+-- 下一个任务的到期时间，没有任务时为 -1
+local next = -1;
+local first = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES');
+if first[2] ~= nil then
+    next = tonumber(first[2]);
+end;
+
+-- 弹出就绪任务的同时取走任务内容，不会残留已弹出但未取走的任务
+while true do
+    local id = redis.call('LPOP', KEYS[3]);
+    if id == false then
+        return {next};
+    end;
+    local item = redis.call('HGET', KEYS[2], id);
+    if item then
+        redis.call('HDEL', KEYS[2], id);
+        return {next, id, item};
+    end;
+end;
+`)
+
+// rDelayedCancelScript ...
+var rDelayedCancelScript = redis.NewScript(`
+if (redis.call('ZREM', KEYS[1], ARGV[1]) == 0) and (redis.call('LREM', KEYS[3], 0, ARGV[1]) == 0) then
+    return 0;
+end;
+return redis.call('HDEL', KEYS[2], ARGV[1]);
+`)
+
+// DelayedItem 延时任务
+type DelayedItem[T any] struct {
+	// ID 任务ID，可用于取消
+	ID string
+	// DueAt 到期时间
+	DueAt time.Time
+	// Payload 任务内容
+	Payload T
+}
+
+// delayedEnvelope redis中存储的任务内容
+type delayedEnvelope struct {
+	DueAt   int64           `json:"due_at"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// RDelayedQueue 基于有序集合的延时队列
+// 任务按到期时间存入有序集合，到期后由消费者通过脚本转移到就绪队列并原子地弹出、取走内容，每个任务只会被一个消费者取走
+type RDelayedQueue[T any] struct {
+	rdb  redis.Cmdable
+	name string
+}
+
+// NewRDelayedQueue 集群模式下 name 需带 hash tag
+func NewRDelayedQueue[T any](rdb redis.Cmdable, name string) *RDelayedQueue[T] {
+	return &RDelayedQueue[T]{
+		rdb:  rdb,
+		name: name,
+	}
+}
+
+// keys 延时有序集合、任务内容、就绪队列
+func (q *RDelayedQueue[T]) keys() []string {
+	return []string{q.name + ":delayed", q.name + ":items", q.name + ":ready"}
+}
+
+// Offer delay 后到期
+func (q *RDelayedQueue[T]) Offer(ctx context.Context, payload T, delay time.Duration) (string, error) {
+	return q.OfferAt(ctx, payload, time.Now().Add(delay))
+}
+
+// OfferAt 指定时间到期，返回任务ID
+func (q *RDelayedQueue[T]) OfferAt(ctx context.Context, payload T, at time.Time) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	dueAt := at.UnixNano() / int64(time.Millisecond)
+	item, err := json.Marshal(delayedEnvelope{DueAt: dueAt, Payload: data})
+	if err != nil {
+		return "", err
+	}
+
+	id := NewOwnerToken()
+	if err = rDelayedOfferScript.Run(ctx, q.rdb, q.keys(), id, dueAt, item).Err(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Cancel 取消未被取走的任务，返回是否取消成功
+func (q *RDelayedQueue[T]) Cancel(ctx context.Context, id string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	ret, err := rDelayedCancelScript.Run(ctx, q.rdb, q.keys(), id).Int64()
+	if err != nil {
+		return false, err
+	}
+	return ret == 1, nil
+}
+
+// Len 未被取走的任务数
+func (q *RDelayedQueue[T]) Len(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return q.rdb.HLen(ctx, q.keys()[1]).Result()
+}
+
+// Take 阻塞等待并取走一个到期任务，直到 ctx 取消
+func (q *RDelayedQueue[T]) Take(ctx context.Context) (*DelayedItem[T], error) {
+	keys := q.keys()
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// 脚本弹出的任务必须取走，避免 ctx 取消时丢失
+		now := time.Now().UnixNano() / int64(time.Millisecond)
+		ret, err := rDelayedTakeScript.Run(context.WithoutCancel(ctx), q.rdb, keys, now, DelayedMoveBatch).Slice()
+		if err != nil {
+			return nil, err
+		}
+		if len(ret) == 3 {
+			return q.decode(ret[1].(string), ret[2].(string))
+		}
+
+		// 下一个任务在轮询间隔内到期时，等待到期后直接转移
+		if next := ret[0].(int64); next > 0 {
+			if wait := time.Duration(next-now) * time.Millisecond; wait < DelayedPollInterval {
+				if !sleepContext(ctx, wait) {
+					return nil, ctx.Err()
+				}
+				continue
+			}
+		}
+
+		// 阻塞等待就绪队列非空，队首任务原样放回，由脚本取走
+		err = q.rdb.BLMove(ctx, keys[2], keys[2], "LEFT", "LEFT", DelayedPollInterval).Err()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+	}
+}
+
+// decode ...
+func (q *RDelayedQueue[T]) decode(id, data string) (*DelayedItem[T], error) {
+	var envelope delayedEnvelope
+	if err := json.Unmarshal([]byte(data), &envelope); err != nil {
+		return nil, err
+	}
+	item := &DelayedItem[T]{
+		ID:    id,
+		DueAt: time.Unix(0, envelope.DueAt*int64(time.Millisecond)),
+	}
+	if err := json.Unmarshal(envelope.Payload, &item.Payload); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
@@ -0,0 +1,96 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDelayedQueue ...
+func TestDelayedQueue(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx := context.Background()
+
+	q := NewRDelayedQueue[task](rdb, "{orders}")
+	start := time.Now()
+	for i, name := range []string{"third", "first", "second"} {
+		delay := []time.Duration{300, 100, 200}[i] * time.Millisecond
+		if _, err := q.Offer(ctx, task{Name: name}, delay); err != nil {
+			t.Fatalf("offer fail, err: %v", err)
+		}
+	}
+	cancelled, _ := q.Offer(ctx, task{Name: "cancelled"}, 150*time.Millisecond)
+	if ok, err := q.Cancel(ctx, cancelled); !ok || err != nil {
+		t.Fatalf("cancel fail, ok: %v err: %v", ok, err)
+	}
+	if ok, _ := q.Cancel(ctx, cancelled); ok {
+		t.Fatalf("expected cancel twice fail")
+	}
+	if n, _ := q.Len(ctx); n != 3 {
+		t.Fatalf("expected 3 items, got %d", n)
+	}
+
+	// 按到期时间顺序取出
+	for _, name := range []string{"first", "second", "third"} {
+		item, err := q.Take(ctx)
+		if err != nil || item.Payload.Name != name {
+			t.Fatalf("expected %s, got %+v err: %v", name, item, err)
+		}
+		if time.Now().Before(item.DueAt) {
+			t.Fatalf("item %s taken before due", name)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond || elapsed > 900*time.Millisecond {
+		t.Fatalf("unexpected elapsed %v", elapsed)
+	}
+
+	// 多个消费者不会重复取走
+	for i := 0; i < 20; i++ {
+		q.Offer(ctx, task{Name: "batch"}, 0)
+	}
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		taken = make(map[string]bool)
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				timeout, cancel := context.WithTimeout(ctx, 1500*time.Millisecond)
+				item, err := q.Take(timeout)
+				cancel()
+				if err != nil {
+					return
+				}
+				mutex.Lock()
+				if taken[item.ID] {
+					t.Errorf("duplicate item %s", item.ID)
+				}
+				taken[item.ID] = true
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(taken) != 20 {
+		t.Fatalf("expected 20 items, got %d", len(taken))
+	}
+	// 取走的任务不残留在任务内容中
+	if n, _ := q.Len(ctx); n != 0 || s.Exists("{orders}:items") {
+		t.Fatalf("expected no items left, got %d", n)
+	}
+
+	// ctx 取消
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	q.Offer(ctx, task{Name: "later"}, time.Minute)
+	if _, err := q.Take(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
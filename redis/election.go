@@ -0,0 +1,302 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+const (
+	// ElectionPrefix 选举key前缀
+	ElectionPrefix = "election:"
+	// ElectionTermSuffix 任期计数key后缀
+	ElectionTermSuffix = ":term"
+	// ElectionChannel 卸任广播频道前缀
+	ElectionChannel = "ElectionChannel:"
+	// ElectionLease 默认领导者租约时间，每隔1/3续期一次
+	ElectionLease = 15 * time.Second
+)
+
+// ErrCampaigning 同一个 RElection 已在参选
+var ErrCampaigning = errors.New("election campaign already running")
+
+// rCampaignScript ...
+var rCampaignScript = redis.NewScript(`
+-- 已是领导者：续期
+if (redis.call('GET', KEYS[1]) == ARGV[1]) then
+    redis.call('PEXPIRE', KEYS[1], ARGV[2]);
+    return {tonumber(redis.call('GET', KEYS[2])), 0};
+end;
+
+-- 当选：开始新任期
+if (redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2], 'NX')) then
+    return {redis.call('INCR', KEYS[2]), 0};
+end;
+
+return {0, redis.call('PTTL', KEYS[1])};
+`)
+
+// rResignScript ...
+var rResignScript = redis.NewScript(`
+if (redis.call('GET', KEYS[1]) == ARGV[1]) then
+    redis.call('DEL', KEYS[1]);
+    redis.call('PUBLISH', KEYS[2], ARGV[1]);
+    return 1;
+end;
+return 0;
+`)
+
+// RElection 基于redis租约的领导者选举
+// 每次当选任期号递增，可作为防护令牌(fencing token)交给下游拒绝过期领导者的写入
+type RElection struct {
+	rdb   redis.Cmdable
+	name  string
+	token string
+	lease time.Duration
+
+	onElected func(ctx context.Context, term int64)
+	onRevoked func()
+
+	mutex      sync.Mutex
+	term       int64
+	validUntil time.Time
+	resign     chan struct{}
+	done       chan struct{}
+}
+
+// NewRElection name 为选举名称，同名的实例竞选同一个领导者，集群模式下 name 需带 hash tag
+func NewRElection(rdb redis.Cmdable, name string) *RElection {
+	return &RElection{
+		rdb:   rdb,
+		name:  name,
+		token: NewOwnerToken(),
+		lease: ElectionLease,
+	}
+}
+
+// SetLease 设置租约时间，领导者失联超过租约时间后其他实例可当选
+func (e *RElection) SetLease(lease time.Duration) *RElection {
+	e.lease = lease
+	return e
+}
+
+// OnElected 当选时在新协程中回调，ctx 在卸任时取消
+func (e *RElection) OnElected(fn func(ctx context.Context, term int64)) *RElection {
+	e.onElected = fn
+	return e
+}
+
+// OnRevoked 卸任或失去领导权时回调
+func (e *RElection) OnRevoked(fn func()) *RElection {
+	e.onRevoked = fn
+	return e
+}
+
+// keys ...
+func (e *RElection) keys() []string {
+	return []string{ElectionPrefix + e.name, ElectionPrefix + e.name + ElectionTermSuffix}
+}
+
+// channel ...
+func (e *RElection) channel() string {
+	return ElectionChannel + e.name
+}
+
+// IsLeader 当前是否为领导者，续期失败时在租约到期后即不再视为领导者
+func (e *RElection) IsLeader() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.term > 0 && time.Now().Before(e.validUntil)
+}
+
+// Term 当前任期号，非领导者时返回0
+func (e *RElection) Term() int64 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.term > 0 && time.Now().Before(e.validUntil) {
+		return e.term
+	}
+	return 0
+}
+
+// Leader 当前领导者标识，没有领导者时返回空字符串
+func (e *RElection) Leader(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	leader, err := e.rdb.Get(ctx, e.keys()[0]).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return leader, err
+}
+
+// Token 本实例的参选标识
+func (e *RElection) Token() string {
+	return e.token
+}
+
+// Campaign 参选并阻塞，当选后持续续期，失去领导权后重新参选
+// ctx 取消时返回 ctx.Err()，调用 Resign 后返回 nil，退出前释放领导权
+func (e *RElection) Campaign(ctx context.Context) error {
+	e.mutex.Lock()
+	if e.resign != nil {
+		e.mutex.Unlock()
+		return ErrCampaigning
+	}
+	resign, done := make(chan struct{}), make(chan struct{})
+	e.resign, e.done = resign, done
+	e.mutex.Unlock()
+
+	defer func() {
+		e.mutex.Lock()
+		e.resign, e.done = nil, nil
+		e.mutex.Unlock()
+		close(done)
+	}()
+
+	// 订阅卸任广播，其他实例卸任时立即参选
+	msgs, unsubscribe := subscribe(ctx, e.rdb, e.channel())
+	defer unsubscribe()
+
+	for {
+		start := time.Now()
+		term, ttl, err := e.campaign(ctx)
+		if err == nil && term > 0 {
+			e.lead(ctx, resign, term, start)
+			ttl = 0
+		}
+
+		if ttl <= 0 || ttl > e.lease/3 {
+			ttl = e.lease / 3
+		}
+		timer := time.NewTimer(ttl)
+		select {
+		case <-msgs:
+		case <-timer.C:
+		case <-ctx.Done():
+		case <-resign:
+		}
+		timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			e.release(context.WithoutCancel(ctx))
+			return ctx.Err()
+		case <-resign:
+			e.release(ctx)
+			return nil
+		default:
+		}
+	}
+}
+
+// campaign 返回任期号，未当选时返回领导者租约剩余时间
+func (e *RElection) campaign(ctx context.Context) (int64, time.Duration, error) {
+	ret, err := rCampaignScript.Run(ctx, e.rdb, e.keys(), e.token, int64(e.lease/time.Millisecond)).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	values := ret.([]interface{})
+	return values[0].(int64), time.Duration(values[1].(int64)) * time.Millisecond, nil
+}
+
+// lead 任期内定期续期，直到失去领导权、ctx 取消或卸任
+func (e *RElection) lead(ctx context.Context, resign <-chan struct{}, term int64, start time.Time) {
+	e.mutex.Lock()
+	e.term = term
+	e.validUntil = start.Add(e.lease)
+	e.mutex.Unlock()
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	if e.onElected != nil {
+		go e.onElected(leaderCtx, term)
+	}
+
+	ticker := time.NewTicker(e.lease / 3)
+	for {
+		timer := time.NewTimer(time.Until(e.leaseValidUntil()))
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+		case <-ctx.Done():
+		case <-resign:
+		}
+		timer.Stop()
+		if ctx.Err() != nil || isClosed(resign) {
+			break
+		}
+
+		start = time.Now()
+		renewed, _, err := e.campaign(ctx)
+		if err == nil && renewed == term {
+			e.mutex.Lock()
+			e.validUntil = start.Add(e.lease)
+			e.mutex.Unlock()
+			continue
+		}
+		// 租约已被他人占用，或网络错误持续到租约到期
+		if err == nil || !time.Now().Before(e.leaseValidUntil()) {
+			break
+		}
+	}
+	ticker.Stop()
+
+	e.mutex.Lock()
+	e.term = 0
+	e.mutex.Unlock()
+	cancel()
+
+	if e.onRevoked != nil {
+		e.onRevoked()
+	}
+}
+
+// leaseValidUntil ...
+func (e *RElection) leaseValidUntil() time.Time {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.validUntil
+}
+
+// release ...
+func (e *RElection) release(ctx context.Context) {
+	if err := rResignScript.Run(ctx, e.rdb, []string{e.keys()[0], e.channel()}, e.token).Err(); err != nil {
+		defaultLogger.Printf("election %s resign fail, err: %v", e.name, err)
+	}
+}
+
+// Resign 卸任并停止参选，等待 Campaign 释放领导权后返回
+func (e *RElection) Resign(ctx context.Context) error {
+	e.mutex.Lock()
+	resign, done := e.resign, e.done
+	if resign != nil && !isClosed(resign) {
+		close(resign)
+	}
+	e.mutex.Unlock()
+
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isClosed ...
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,94 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"testing"
+	"time"
+)
+
+// TestElection ...
+func TestElection(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	elected := make(chan int64, 4)
+	revoked := make(chan string, 4)
+	newElection := func(name string) *RElection {
+		return NewRElection(rdb, "{cron}").
+			SetLease(300 * time.Millisecond).
+			OnElected(func(ctx context.Context, term int64) {
+				elected <- term
+				<-ctx.Done()
+			}).
+			OnRevoked(func() { revoked <- name })
+	}
+	a, b := newElection("a"), newElection("b")
+
+	results := make(chan error, 2)
+	go func() { results <- a.Campaign(ctx) }()
+	first := <-elected
+	go func() { results <- b.Campaign(ctx) }()
+
+	if !a.IsLeader() || a.Term() != first || b.IsLeader() {
+		t.Fatalf("expected a leader, a: %v b: %v", a.IsLeader(), b.IsLeader())
+	}
+	if leader, _ := b.Leader(ctx); leader != a.Token() {
+		t.Fatalf("unexpected leader %s", leader)
+	}
+	if err := a.Campaign(ctx); !errors.Is(err, ErrCampaigning) {
+		t.Fatalf("expected ErrCampaigning, got %v", err)
+	}
+
+	// 续期保持领导权
+	time.Sleep(time.Second)
+	if !a.IsLeader() || len(elected) != 0 {
+		t.Fatalf("expected a still leader")
+	}
+
+	// 卸任后其他实例立即当选，任期号递增
+	start := time.Now()
+	if err := a.Resign(ctx); err != nil || <-results != nil {
+		t.Fatalf("resign fail, err: %v", err)
+	}
+	if name := <-revoked; name != "a" || a.IsLeader() {
+		t.Fatalf("expected a revoked, got %s", name)
+	}
+	second := <-elected
+	if second != first+1 || !b.IsLeader() || time.Since(start) > 200*time.Millisecond {
+		t.Fatalf("expected b elected with term %d, got %d after %v", first+1, second, time.Since(start))
+	}
+
+	// 租约被他人占用后失去领导权
+	s.Set(ElectionPrefix+"{cron}", "other")
+	select {
+	case name := <-revoked:
+		if name != "b" || b.IsLeader() || b.Term() != 0 {
+			t.Fatalf("expected b revoked, got %s", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected b revoked")
+	}
+
+	// 领导者失联，租约过期后重新当选
+	s.Del(ElectionPrefix + "{cron}")
+	select {
+	case third := <-elected:
+		if third != second+1 {
+			t.Fatalf("expected term %d, got %d", second+1, third)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected b re-elected")
+	}
+
+	cancel()
+	if err := <-results; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	if s.Exists(ElectionPrefix + "{cron}") {
+		t.Fatalf("expected leadership released")
+	}
+}
@@ -0,0 +1,77 @@
+package redisson
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrNotConnected redis连接失败，构造函数返回 nil 时调用方法返回该错误
+	ErrNotConnected = errors.New("redis conn err")
+	// ErrLockHeld 锁被其他持有者占用，具体剩余过期时间见 LockHeldError
+	ErrLockHeld = errors.New("lock held by another owner")
+	// ErrNotOwner 不允许解锁其他持有者持有的锁
+	ErrNotOwner = errors.New("lock not owned by current owner")
+	// ErrLockNotFound 锁不存在或已过期
+	ErrLockNotFound = errors.New("lock not found")
+	// ErrTimeout 等待超时
+	ErrTimeout = errors.New("timeout")
+	// ErrInvalidExpiration 锁过期时间小于1ms
+	ErrInvalidExpiration = errors.New("lock expiration must be at least 1ms")
+)
+
+// LockHeldError 锁被其他持有者占用，errors.Is(err, ErrLockHeld) 为 true
+type LockHeldError struct {
+	Name string
+	TTL  time.Duration
+}
+
+// Error ...
+func (e *LockHeldError) Error() string {
+	return fmt.Sprintf("lock %s held by another owner, ttl %v", e.Name, e.TTL)
+}
+
+// Is ...
+func (e *LockHeldError) Is(target error) bool {
+	return target == ErrLockHeld
+}
+
+// lockHeld 加锁脚本返回的剩余过期时间(毫秒)转换为错误
+func lockHeld(name string, ret int64) error {
+	return &LockHeldError{Name: name, TTL: time.Duration(ret) * time.Millisecond}
+}
+
+// unlockErr 解锁脚本返回值转换为错误，含义同 rUnlockMsg
+func unlockErr(ret int64) error {
+	switch ret {
+	case 1:
+		return ErrLockNotFound
+	case 2:
+		return ErrNotOwner
+	default:
+		return nil
+	}
+}
+
+// Logger 日志接口，logrus.Logger、标准库 log.Logger 均实现
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// nopLogger 默认不输出日志
+type nopLogger struct{}
+
+// Printf ...
+func (nopLogger) Printf(string, ...interface{}) {}
+
+// defaultLogger 新建RLock时使用的日志
+var defaultLogger Logger = nopLogger{}
+
+// SetLogger 设置新建RLock时使用的默认日志，传入 nil 时不输出日志
+func SetLogger(logger Logger) {
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	defaultLogger = logger
+}
@@ -0,0 +1,268 @@
+package redisson
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	// FairLockQueuePrefix 公平锁等待队列前缀
+	FairLockQueuePrefix = "redisson_lock_queue:"
+	// FairLockTimeoutPrefix 公平锁等待者超时时间前缀
+	FairLockTimeoutPrefix = "redisson_lock_timeout:"
+	// FairLockWaitTime 等待者心跳超时时间，超过该时间未重试的等待者被移出队列
+	FairLockWaitTime = 5 * time.Second
+)
+
+// rFairLockScript ...
+var rFairLockScript = redis.NewScript(`
+-- 从队首开始移除心跳已超时的等待者
+while true do
+    local first = redis.call('LINDEX', KEYS[2], 0);
+    if first == false then
+        break;
+    end;
+    local deadline = tonumber(redis.call('ZSCORE', KEYS[3], first));
+    if deadline == nil or deadline <= tonumber(ARGV[4]) then
+        redis.call('ZREM', KEYS[3], first);
+        redis.call('LPOP', KEYS[2]);
+    else
+        break;
+    end;
+end;
+
+-- 若锁不存在，且无人排队或当前线程位于队首：则出队并加锁
+if (redis.call('exists', KEYS[1]) == 0) and ((redis.call('exists', KEYS[2]) == 0) or (redis.call('LINDEX', KEYS[2], 0) == ARGV[2])) then
+    redis.call('LPOP', KEYS[2]);
+    redis.call('ZREM', KEYS[3], ARGV[2]);
+    redis.call('HSET', KEYS[1], ARGV[2], 1);
+    redis.call('PEXPIRE', KEYS[1], ARGV[1]);
+    return -1;
+end;
+
+-- 若锁存在，且唯一标识也匹配：锁重入
+if (redis.call('HEXISTS', KEYS[1], ARGV[2]) == 1) then
+    redis.call('HINCRBY', KEYS[1], ARGV[2], 1);
+    redis.call('PEXPIRE', KEYS[1], ARGV[1]);
+    return -2;
+end;
+
+-- 锁已释放但队首是其他等待者时，最多等待一个心跳周期
+local ttl = redis.call('PTTL', KEYS[1]);
+if ttl <= 0 then
+    ttl = tonumber(ARGV[3]);
+end;
+
+-- 需要排队时：入队或刷新心跳超时时间
+if ARGV[5] == '1' then
+    if redis.call('ZADD', KEYS[3], tonumber(ARGV[4]) + tonumber(ARGV[3]), ARGV[2]) == 1 then
+        redis.call('RPUSH', KEYS[2], ARGV[2]);
+    end;
+    redis.call('PEXPIRE', KEYS[2], ARGV[3]);
+    redis.call('PEXPIRE', KEYS[3], ARGV[3]);
+end;
+return ttl;
+`)
+
+// rFairUnlockScript ...
+var rFairUnlockScript = redis.NewScript(`
+-- 若锁不存在：通知队首等待者
+if (redis.call('exists', KEYS[1]) == 0) then
+    local next = redis.call('LINDEX', KEYS[2], 0);
+    if next ~= false then
+        redis.call('publish', KEYS[4] .. ':' .. next, ARGV[1]);
+    end;
+    return 1;
+end;
+
+-- 若锁存在，但唯一标识不匹配：不允许解锁其他线程持有的锁
+if (redis.call('hexists', KEYS[1], ARGV[3]) == 0) then
+    return 2;
+end;
+
+-- 锁重入计数减1，仍大于0时只续期
+local counter = redis.call('hincrby', KEYS[1], ARGV[3], -1);
+if (counter > 0) then
+    redis.call('pexpire', KEYS[1], ARGV[2]);
+    return 3;
+end;
+
+-- 删除锁，并只唤醒队首等待者
+redis.call('del', KEYS[1]);
+local next = redis.call('LINDEX', KEYS[2], 0);
+if next ~= false then
+    redis.call('publish', KEYS[4] .. ':' .. next, ARGV[1]);
+end;
+return 4;
+`)
+
+// rFairDequeueScript ...
+var rFairDequeueScript = redis.NewScript(`
+-- 放弃等待：移出队列
+redis.call('ZREM', KEYS[2], ARGV[1]);
+redis.call('LREM', KEYS[1], 0, ARGV[1]);
+return 1;
+`)
+
+// RFairLock 公平锁，等待者按到达顺序获得锁
+type RFairLock struct {
+	rLock    *RLock
+	waitTime time.Duration
+	now      func() time.Time
+}
+
+// NewRFairLock ...
+func NewRFairLock(ctx context.Context, rdb redis.Cmdable) *RFairLock {
+	rLock := NewRLock(ctx, rdb)
+	if rLock == nil {
+		return nil
+	}
+
+	return &RFairLock{
+		rLock:    rLock,
+		waitTime: FairLockWaitTime,
+		now:      time.Now,
+	}
+}
+
+// WithOwner 返回绑定持有者标识的RFairLock副本，使用相同持有者标识加锁即为锁重入
+func (fLock *RFairLock) WithOwner(owner string) *RFairLock {
+	if fLock == nil {
+		return nil
+	}
+
+	return &RFairLock{
+		rLock:    fLock.rLock.WithOwner(owner),
+		waitTime: fLock.waitTime,
+		now:      fLock.now,
+	}
+}
+
+// WithMetrics 返回记录prometheus指标的RFairLock副本
+func (fLock *RFairLock) WithMetrics(metrics *LockMetrics) *RFairLock {
+	if fLock == nil {
+		return nil
+	}
+
+	return &RFairLock{
+		rLock:    fLock.rLock.WithMetrics(metrics),
+		waitTime: fLock.waitTime,
+		now:      fLock.now,
+	}
+}
+
+// SetWaitTime 设置等待者心跳超时时间
+func (fLock *RFairLock) SetWaitTime(waitTime time.Duration) *RFairLock {
+	fLock.waitTime = waitTime
+	return fLock
+}
+
+// fairKeys 锁、等待队列、等待者超时时间，均在同一 slot
+func fairKeys(name string) []string {
+	return []string{
+		name,
+		FairLockQueuePrefix + hashTag(name),
+		FairLockTimeoutPrefix + hashTag(name),
+	}
+}
+
+// acquire 执行公平锁加锁脚本，enqueue 为 true 时未获得锁则排队
+func (fLock *RFairLock) acquire(ctx context.Context, name, token string, expiration time.Duration, enqueue bool) (int64, error) {
+	flag := 0
+	if enqueue {
+		flag = 1
+	}
+
+	now := fLock.now().UnixNano() / int64(time.Millisecond)
+	ret, err := rFairLockScript.Run(ctx, fLock.rLock.rdb, fairKeys(name), int64(expiration), token, int64(fLock.waitTime/time.Millisecond), now, flag).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	fLock.rLock.printLog(name, token, ret)
+	return ret.(int64), nil
+}
+
+// newHandle ...
+func (fLock *RFairLock) newHandle(name, token string, expiration time.Duration) *LockHandle {
+	h := fLock.rLock.newHandle(name, token, expiration)
+	h.release = releaseFairLock
+	return h
+}
+
+// Lock 仅在锁空闲且无人排队时加锁，不进入等待队列，锁被占用时返回 *LockHeldError
+func (fLock *RFairLock) Lock(ctx context.Context, name string, args ...time.Duration) (h *LockHandle, err error) {
+	if fLock == nil {
+		return nil, ErrNotConnected
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	defer func() { fLock.rLock.metrics.observeAcquire(name, start, err) }()
+
+	expiration, err := fLock.rLock.buildLockArgs(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	token := fLock.rLock.token()
+	ret, err := fLock.acquire(ctx, name, token, expiration, false)
+	if err != nil {
+		return nil, err
+	}
+	if ret > 0 {
+		return nil, lockHeld(name, ret)
+	}
+
+	return fLock.newHandle(name, token, expiration), nil
+}
+
+// TryLock 进入等待队列，按到达顺序获得锁，直到超时返回 ErrTimeout 或 ctx 取消
+func (fLock *RFairLock) TryLock(ctx context.Context, name string, args ...time.Duration) (h *LockHandle, err error) {
+	if fLock == nil {
+		return nil, ErrNotConnected
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	defer func() { fLock.rLock.metrics.observeAcquire(name, start, err) }()
+
+	expiration, timeout, err := fLock.rLock.buildTryLockArgs(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	token := fLock.rLock.token()
+	ret, err := fLock.acquire(ctx, name, token, expiration, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret > 0 {
+		if err = fLock.waitLock(ctx, name, token, expiration, timeout); err != nil {
+			// 放弃等待时移出队列，避免阻塞后续等待者
+			keys := fairKeys(name)
+			rFairDequeueScript.Run(context.WithoutCancel(ctx), fLock.rLock.rdb, keys[1:], token)
+			return nil, err
+		}
+	}
+
+	return fLock.newHandle(name, token, expiration), nil
+}
+
+// waitLock 订阅当前等待者的唤醒频道，并在心跳超时前重试刷新排队位置
+func (fLock *RFairLock) waitLock(ctx context.Context, name, token string, expiration, timeout time.Duration) error {
+	return waitAcquire(ctx, fLock.rLock.rdb, releaseChannel(name)+":"+token, timeout, fLock.waitTime/2, func() (int64, error) {
+		return fLock.acquire(ctx, name, token, expiration, true)
+	})
+}
+
+// releaseFairLock 释放公平锁，并唤醒队首等待者
+func releaseFairLock(ctx context.Context, h *LockHandle) (interface{}, error) {
+	keys := append(fairKeys(h.name), releaseChannel(h.name))
+	expiration := int64(h.expiration / time.Millisecond)
+	return rFairUnlockScript.Run(ctx, h.rLock.rdb, keys, LockReleaseFlag, expiration, h.token).Result()
+}
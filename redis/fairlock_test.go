@@ -0,0 +1,121 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestFairLockOrder ...
+func TestFairLockOrder(t *testing.T) {
+	rdb := redistest.Run(t).Client()
+	ctx := context.Background()
+	fLock := NewRFairLock(ctx, rdb)
+
+	holder, err := fLock.Lock(ctx, "myFairLock")
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		order []int
+	)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l, err := fLock.TryLock(ctx, "myFairLock", LockExpiration, 5*time.Second)
+			if err != nil {
+				t.Errorf("lock fail, err: %v", err)
+				return
+			}
+			mutex.Lock()
+			order = append(order, i)
+			mutex.Unlock()
+			time.Sleep(50 * time.Millisecond)
+			l.Unlock(ctx)
+		}(i)
+		// 保证等待者按顺序入队
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	holder.Unlock(ctx)
+	wg.Wait()
+
+	for i, v := range order {
+		if i != v {
+			t.Fatalf("expected FIFO order, got %v", order)
+		}
+	}
+	if len(order) != 3 {
+		t.Fatalf("expected 3 acquisitions, got %v", order)
+	}
+}
+
+// TestFairLockEvictDeadWaiter ...
+func TestFairLockEvictDeadWaiter(t *testing.T) {
+	s := redistest.Run(t, redistest.WithFrozenClock())
+	rdb := s.Client()
+	ctx := context.Background()
+	fLock := NewRFairLock(ctx, rdb).SetWaitTime(300 * time.Millisecond)
+	fLock.now = s.Now
+
+	holder, err := fLock.Lock(ctx, "myFairEvictLock")
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+
+	// 入队后不再心跳的等待者
+	expiration := LockExpiration / time.Millisecond
+	if _, err = fLock.acquire(ctx, "myFairEvictLock", "dead-waiter", expiration, true); err != nil {
+		t.Fatalf("enqueue fail, err: %v", err)
+	}
+	holder.Unlock(ctx)
+
+	// 队首等待者心跳未超时，其他等待者需排队
+	s.Advance(200 * time.Millisecond)
+	if ret, err := fLock.acquire(ctx, "myFairEvictLock", "live-waiter", expiration, true); err != nil || ret <= 0 {
+		t.Fatalf("expected live waiter queued, ret: %d err: %v", ret, err)
+	}
+
+	// 心跳超时后移出队列
+	s.Advance(100 * time.Millisecond)
+	if ret, err := fLock.acquire(ctx, "myFairEvictLock", "live-waiter", expiration, true); err != nil || ret != -1 {
+		t.Fatalf("expected dead waiter evicted, ret: %d err: %v", ret, err)
+	}
+}
+
+// TestFairLockCluster ...
+func TestFairLockCluster(t *testing.T) {
+	rdb := redistest.Run(t).ClusterClient()
+	ctx := context.Background()
+	fLock := NewRFairLock(ctx, rdb)
+
+	for _, name := range []string{"myClusterFairLock", "{order}:fair"} {
+		holder, err := fLock.Lock(ctx, name)
+		if err != nil {
+			t.Fatalf("%s: lock fail, err: %v", name, err)
+		}
+
+		// 排队等待及放弃等待
+		if _, err = fLock.TryLock(ctx, name, LockExpiration, 50*time.Millisecond); !errors.Is(err, ErrTimeout) {
+			t.Fatalf("%s: expected ErrTimeout, got %v", name, err)
+		}
+		if err = holder.Unlock(ctx); err != nil {
+			t.Fatalf("%s: unlock fail, err: %v", name, err)
+		}
+
+		l, err := fLock.TryLock(ctx, name, LockExpiration, time.Second)
+		if err != nil {
+			t.Fatalf("%s: lock fail, err: %v", name, err)
+		}
+		if err = l.Unlock(ctx); err != nil {
+			t.Fatalf("%s: unlock fail, err: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package redisson
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rForceUnlockScript ...
+var rForceUnlockScript = redis.NewScript(`
+redis.call('DEL', KEYS[3], KEYS[4], KEYS[5]);
+if (redis.call('DEL', KEYS[1]) == 1) then
+    redis.call('PUBLISH', KEYS[2], ARGV[1]);
+    return 1;
+end;
+return 0;
+`)
+
+// LockInfo 锁的持有情况
+type LockInfo struct {
+	Name string
+	// Locked 锁是否被持有
+	Locked bool
+	// Mode 读写锁的模式 read/write，其他锁为空
+	Mode string
+	// Holder 唯一持有者的 field(持有者标识，写锁带 ":write" 后缀)，多个读锁持有者时为空
+	Holder string
+	// Count 唯一持有者的重入次数
+	Count int64
+	// Holders 所有持有者 field 及重入次数
+	Holders map[string]int64
+	// TTL 锁剩余过期时间
+	TTL time.Duration
+}
+
+// Inspect 查看锁的持有者、重入次数及剩余过期时间，适用于互斥锁、公平锁、读写锁、单节点上的多节点锁
+func (rLock *RLock) Inspect(ctx context.Context, name string) (*LockInfo, error) {
+	if rLock == nil {
+		return nil, ErrNotConnected
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fields, err := rLock.rdb.HGetAll(ctx, name).Result()
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := rLock.rdb.PTTL(ctx, name).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &LockInfo{Name: name, Holders: make(map[string]int64)}
+	for field, value := range fields {
+		if field == "mode" {
+			info.Mode = value
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		info.Holders[field] = count
+	}
+	if len(info.Holders) == 0 {
+		return info, nil
+	}
+
+	info.Locked = true
+	info.TTL = ttl
+	if len(info.Holders) == 1 || info.Mode == "write" {
+		for field, count := range info.Holders {
+			// 写锁持有者可能同时持有读锁，取写锁 field
+			if info.Mode == "write" && !strings.HasSuffix(field, RWLockWriteSuffix) {
+				continue
+			}
+			info.Holder, info.Count = field, count
+		}
+	}
+	return info, nil
+}
+
+// ForceUnlock 不校验持有者强制删除锁并唤醒等待方，返回锁是否存在
+// 支持互斥锁、读写锁及公平锁，公平锁的等待队列一并删除，等待方在下次心跳时重新排队
+// 仅用于运维处理卡死的锁，原持有者之后解锁将返回 ErrLockNotFound
+func (rLock *RLock) ForceUnlock(ctx context.Context, name string) (bool, error) {
+	if rLock == nil {
+		return false, ErrNotConnected
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	keys := append([]string{name, releaseChannel(name), rwKeys(name)[1]}, fairKeys(name)[1:]...)
+	ret, err := rForceUnlockScript.Run(ctx, rLock.rdb, keys, LockReleaseFlag).Int64()
+	if err != nil {
+		return false, err
+	}
+	rLock.logger.Printf("force unlock name=%s code=%v", name, ret)
+	return ret == 1, nil
+}
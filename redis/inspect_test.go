@@ -0,0 +1,80 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"testing"
+	"time"
+)
+
+// TestInspect ...
+func TestInspect(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx := context.Background()
+
+	rLock := NewRLock(ctx, rdb).WithOwner("owner")
+	if info, err := rLock.Inspect(ctx, "inspect"); err != nil || info.Locked {
+		t.Fatalf("expected unlocked, info: %+v err: %v", info, err)
+	}
+
+	rLock.Lock(ctx, "inspect", 10*time.Second)
+	h, _ := rLock.Lock(ctx, "inspect", 10*time.Second)
+	info, err := rLock.Inspect(ctx, "inspect")
+	if err != nil || !info.Locked || info.Holder != "owner" || info.Count != 2 || info.TTL <= 0 || info.TTL > 10*time.Second {
+		t.Fatalf("unexpected info %+v err: %v", info, err)
+	}
+
+	// 读写锁
+	rw := NewRReadWriteLock(ctx, rdb, "inspect:rw").WithOwner("writer")
+	rw.WriteLock().Lock(ctx)
+	rw.ReadLock().Lock(ctx)
+	info, _ = rLock.Inspect(ctx, "inspect:rw")
+	if info.Mode != "write" || info.Holder != "writer"+RWLockWriteSuffix || info.Count != 1 || len(info.Holders) != 2 {
+		t.Fatalf("unexpected rwlock info %+v", info)
+	}
+
+	// 强制解锁唤醒等待方，原持有者解锁失败
+	waited := make(chan error, 1)
+	go func() {
+		_, err := NewRLock(ctx, rdb).TryLock(ctx, "inspect", 10*time.Second, 5*time.Second)
+		waited <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	if ok, err := rLock.ForceUnlock(ctx, "inspect"); !ok || err != nil {
+		t.Fatalf("force unlock fail, err: %v", err)
+	}
+	select {
+	case err = <-waited:
+		if err != nil {
+			t.Fatalf("expected waiter acquired, err: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected waiter woken by force unlock")
+	}
+	if err = h.Unlock(ctx); !errors.Is(err, ErrNotOwner) {
+		t.Fatalf("expected ErrNotOwner, got %v", err)
+	}
+
+	if ok, _ := rLock.ForceUnlock(ctx, "inspect:rw"); !ok || s.Exists(rwKeys("inspect:rw")[1]) {
+		t.Fatalf("expected rwlock force unlocked")
+	}
+	fLock := NewRFairLock(ctx, rdb)
+	if _, err = fLock.Lock(ctx, "inspect:fair"); err != nil {
+		t.Fatalf("fair lock fail, err: %v", err)
+	}
+	go fLock.TryLock(ctx, "inspect:fair", 10*time.Second, 100*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	keys := fairKeys("inspect:fair")
+	if !s.Exists(keys[1]) {
+		t.Fatalf("expected fair waiter queued")
+	}
+	if ok, _ := rLock.ForceUnlock(ctx, "inspect:fair"); !ok || s.Exists(keys[1]) || s.Exists(keys[2]) {
+		t.Fatalf("expected fair lock queue removed")
+	}
+	if ok, _ := rLock.ForceUnlock(ctx, "inspect:none"); ok {
+		t.Fatalf("expected missing lock")
+	}
+}
@@ -0,0 +1,310 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+const (
+	// CountDownLatchChannel 计数归零广播频道前缀
+	CountDownLatchChannel = "CountDownLatchChannel:"
+	// BarrierChannel 屏障放行广播频道前缀
+	BarrierChannel = "BarrierChannel:"
+	// LatchPollInterval 未收到广播时的检查间隔
+	LatchPollInterval = time.Second
+	// BarrierBrokenTTL 被重置的代的记录保留时间，等待方需在此时间内检查到重置
+	BarrierBrokenTTL = time.Hour
+)
+
+var (
+	// ErrBarrierBroken 等待中的屏障被重置
+	ErrBarrierBroken = errors.New("barrier broken")
+	// ErrInvalidLatchCount 闭锁计数需为正数
+	ErrInvalidLatchCount = errors.New("latch count must be positive")
+	// ErrInvalidParties 屏障参与方数量需为正数
+	ErrInvalidParties = errors.New("barrier parties must be positive")
+)
+
+// rTrySetCountScript ...
+var rTrySetCountScript = redis.NewScript(`
+if (redis.call('EXISTS', KEYS[1]) == 1) then
+    return 0;
+end;
+redis.call('SET', KEYS[1], ARGV[1]);
+return 1;
+`)
+
+// rCountDownScript ...
+var rCountDownScript = redis.NewScript(`
+if (redis.call('EXISTS', KEYS[1]) == 0) then
+    return 0;
+end;
+
+local count = redis.call('DECR', KEYS[1]);
+-- 计数归零：删除并广播唤醒等待方
+if (count <= 0) then
+    redis.call('DEL', KEYS[1]);
+    redis.call('PUBLISH', KEYS[2], 0);
+    return 0;
+end;
+return count;
+`)
+
+// rLatchDeleteScript ...
+var rLatchDeleteScript = redis.NewScript(`
+if (redis.call('DEL', KEYS[1]) == 1) then
+    redis.call('PUBLISH', KEYS[2], 0);
+    return 1;
+end;
+return 0;
+`)
+
+// rBarrierArriveScript ...
+var rBarrierArriveScript = redis.NewScript(`
+local generation = tonumber(redis.call('HGET', KEYS[1], 'generation') or '0');
+local count = redis.call('HINCRBY', KEYS[1], 'count', 1);
+
+-- 最后一方到达：开启下一代并广播放行
+if (count >= tonumber(ARGV[1])) then
+    redis.call('HSET', KEYS[1], 'count', 0);
+    redis.call('HSET', KEYS[1], 'generation', generation + 1);
+    redis.call('PUBLISH', KEYS[2], generation + 1);
+end;
+return {count, generation};
+`)
+
+// rBarrierLeaveScript ...
+var rBarrierLeaveScript = redis.NewScript(`
+-- 仍是同一代时撤回到达计数
+if (tonumber(redis.call('HGET', KEYS[1], 'generation') or '0') == tonumber(ARGV[1])) then
+    redis.call('HINCRBY', KEYS[1], 'count', -1);
+    return 1;
+end;
+return 0;
+`)
+
+// rBarrierResetScript ...
+var rBarrierResetScript = redis.NewScript(`
+local generation = tonumber(redis.call('HGET', KEYS[1], 'generation') or '0');
+redis.call('HSET', KEYS[1], 'count', 0);
+redis.call('HSET', KEYS[1], 'generation', generation + 1);
+-- 按代记录重置，多次重置不会覆盖之前被重置的代
+redis.call('SET', KEYS[3] .. generation, 1, 'PX', ARGV[1]);
+redis.call('PUBLISH', KEYS[2], generation + 1);
+return generation;
+`)
+
+// awaitCondition 订阅频道后循环检查条件，直到满足或 ctx 取消
+func awaitCondition(ctx context.Context, rdb redis.Cmdable, channel string, done func() (bool, error)) error {
+	msgs, unsubscribe := subscribe(ctx, rdb, channel)
+	defer unsubscribe()
+
+	for {
+		ok, err := done()
+		if err != nil || ok {
+			return err
+		}
+
+		timer := time.NewTimer(LatchPollInterval)
+		select {
+		case <-msgs:
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		timer.Stop()
+	}
+}
+
+// RCountDownLatch 分布式闭锁，计数归零前 Await 阻塞
+type RCountDownLatch struct {
+	rdb  redis.Cmdable
+	name string
+}
+
+// NewRCountDownLatch 集群模式下 name 需带 hash tag
+func NewRCountDownLatch(rdb redis.Cmdable, name string) *RCountDownLatch {
+	return &RCountDownLatch{
+		rdb:  rdb,
+		name: name,
+	}
+}
+
+// keys ...
+func (l *RCountDownLatch) keys() []string {
+	return []string{l.name, CountDownLatchChannel + l.name}
+}
+
+// TrySetCount 计数未设置或已归零时设置计数，返回是否设置成功，count<=0 时返回 ErrInvalidLatchCount
+func (l *RCountDownLatch) TrySetCount(ctx context.Context, count int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if count <= 0 {
+		return false, ErrInvalidLatchCount
+	}
+
+	ret, err := rTrySetCountScript.Run(ctx, l.rdb, l.keys(), count).Int64()
+	if err != nil {
+		return false, err
+	}
+	return ret == 1, nil
+}
+
+// CountDown 计数减一，归零时唤醒所有等待方
+func (l *RCountDownLatch) CountDown(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return rCountDownScript.Run(ctx, l.rdb, l.keys()).Err()
+}
+
+// GetCount 当前计数
+func (l *RCountDownLatch) GetCount(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	count, err := l.rdb.Get(ctx, l.name).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+// Delete 删除闭锁并唤醒所有等待方，返回闭锁是否存在
+func (l *RCountDownLatch) Delete(ctx context.Context) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	ret, err := rLatchDeleteScript.Run(ctx, l.rdb, l.keys()).Int64()
+	if err != nil {
+		return false, err
+	}
+	return ret == 1, nil
+}
+
+// Await 阻塞直到计数归零或 ctx 取消
+func (l *RCountDownLatch) Await(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return awaitCondition(ctx, l.rdb, l.keys()[1], func() (bool, error) {
+		count, err := l.GetCount(ctx)
+		return count <= 0, err
+	})
+}
+
+// RCyclicBarrier 分布式循环屏障，parties 方全部到达后一起放行并开启下一代
+type RCyclicBarrier struct {
+	rdb     redis.Cmdable
+	name    string
+	parties int64
+}
+
+// NewRCyclicBarrier 集群模式下 name 需带 hash tag，parties<=0 时返回 ErrInvalidParties
+func NewRCyclicBarrier(rdb redis.Cmdable, name string, parties int64) (*RCyclicBarrier, error) {
+	if parties <= 0 {
+		return nil, ErrInvalidParties
+	}
+
+	return &RCyclicBarrier{
+		rdb:     rdb,
+		name:    name,
+		parties: parties,
+	}, nil
+}
+
+// keys 屏障状态、放行广播频道、被重置的代的记录前缀
+func (b *RCyclicBarrier) keys() []string {
+	return []string{b.name, BarrierChannel + b.name, hashTag(b.name) + ":broken:"}
+}
+
+// Await 到达屏障并等待其余各方，返回到达序号：parties-1 为首个到达，0 为最后到达
+// ctx 取消时撤回本次到达，屏障被 Reset 时返回 ErrBarrierBroken
+func (b *RCyclicBarrier) Await(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	// 先订阅再到达，避免错过放行广播
+	msgs, unsubscribe := subscribe(ctx, b.rdb, b.keys()[1])
+	defer unsubscribe()
+
+	ret, err := rBarrierArriveScript.Run(ctx, b.rdb, b.keys(), b.parties).Result()
+	if err != nil {
+		return 0, err
+	}
+	values := ret.([]interface{})
+	count, generation := values[0].(int64), values[1].(int64)
+	index := b.parties - count
+	if index <= 0 {
+		return 0, nil
+	}
+
+	for {
+		timer := time.NewTimer(LatchPollInterval)
+		select {
+		case <-msgs:
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			// ctx 已取消，撤回使用不可取消的 ctx
+			leaveCtx := context.WithoutCancel(ctx)
+			leave, err := rBarrierLeaveScript.Run(leaveCtx, b.rdb, b.keys(), generation).Int64()
+			if err == nil && leave == 0 {
+				// 撤回前已放行
+				return index, b.broken(leaveCtx, generation)
+			}
+			return 0, ctx.Err()
+		}
+		timer.Stop()
+
+		current, err := b.rdb.HGet(ctx, b.name, "generation").Int64()
+		if err != nil {
+			return 0, err
+		}
+		if current > generation {
+			return index, b.broken(ctx, generation)
+		}
+	}
+}
+
+// broken 检查该代是否被重置
+func (b *RCyclicBarrier) broken(ctx context.Context, generation int64) error {
+	n, err := b.rdb.Exists(ctx, b.keys()[2]+strconv.FormatInt(generation, 10)).Result()
+	if err != nil {
+		return err
+	}
+	if n == 1 {
+		return ErrBarrierBroken
+	}
+	return nil
+}
+
+// NumberWaiting 当前代已到达的数量
+func (b *RCyclicBarrier) NumberWaiting(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	count, err := b.rdb.HGet(ctx, b.name, "count").Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+// Reset 重置屏障，当前代等待中的各方返回 ErrBarrierBroken
+func (b *RCyclicBarrier) Reset(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return rBarrierResetScript.Run(ctx, b.rdb, b.keys(), int64(BarrierBrokenTTL/time.Millisecond)).Err()
+}
@@ -0,0 +1,161 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestCountDownLatch ...
+func TestCountDownLatch(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx := context.Background()
+
+	latch := NewRCountDownLatch(rdb, "migration")
+	if ok, err := latch.TrySetCount(ctx, 3); !ok || err != nil {
+		t.Fatalf("try set count fail, err: %v", err)
+	}
+	if ok, _ := latch.TrySetCount(ctx, 5); ok {
+		t.Fatalf("expected try set count fail while counting")
+	}
+	for _, count := range []int64{0, -1} {
+		if ok, err := NewRCountDownLatch(rdb, "empty").TrySetCount(ctx, count); ok || !errors.Is(err, ErrInvalidLatchCount) {
+			t.Fatalf("expected ErrInvalidLatchCount for %d, got ok: %v err: %v", count, ok, err)
+		}
+	}
+
+	// ctx 超时
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := latch.Await(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	awaited := make(chan time.Time, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := NewRCountDownLatch(rdb, "migration").Await(ctx); err != nil {
+				t.Errorf("await fail, err: %v", err)
+			}
+			awaited <- time.Now()
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if len(awaited) != 0 {
+			t.Fatalf("await returned before count down")
+		}
+		latch.CountDown(ctx)
+	}
+	done := time.Now()
+	wg.Wait()
+	for i := 0; i < 3; i++ {
+		if at := <-awaited; at.Sub(done) > 200*time.Millisecond {
+			t.Fatalf("expected await woken by broadcast, took %v", at.Sub(done))
+		}
+	}
+
+	// 归零后可重新设置
+	if count, _ := latch.GetCount(ctx); count != 0 {
+		t.Fatalf("expected count 0, got %d", count)
+	}
+	if ok, _ := latch.TrySetCount(ctx, 1); !ok {
+		t.Fatalf("expected try set count after zero")
+	}
+	if ok, _ := latch.Delete(ctx); !ok {
+		t.Fatalf("expected delete")
+	}
+}
+
+// TestCyclicBarrier ...
+func TestCyclicBarrier(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx := context.Background()
+
+	// 循环使用两代
+	for round := 0; round < 2; round++ {
+		var (
+			wg      sync.WaitGroup
+			mutex   sync.Mutex
+			indexes []int
+		)
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				barrier, _ := NewRCyclicBarrier(rdb, "shards", 3)
+				index, err := barrier.Await(ctx)
+				if err != nil {
+					t.Errorf("await fail, err: %v", err)
+				}
+				mutex.Lock()
+				indexes = append(indexes, int(index))
+				mutex.Unlock()
+			}()
+		}
+		wg.Wait()
+		sort.Ints(indexes)
+		if len(indexes) != 3 || indexes[0] != 0 || indexes[1] != 1 || indexes[2] != 2 {
+			t.Fatalf("unexpected arrival indexes %v", indexes)
+		}
+	}
+
+	// ctx 取消时撤回到达
+	barrier, err := NewRCyclicBarrier(rdb, "shards", 3)
+	if err != nil {
+		t.Fatalf("new barrier fail, err: %v", err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := barrier.Await(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if n, _ := barrier.NumberWaiting(ctx); n != 0 {
+		t.Fatalf("expected arrival withdrawn, waiting: %d", n)
+	}
+
+	// 重置屏障
+	broken := make(chan error, 1)
+	go func() {
+		_, err := barrier.Await(ctx)
+		broken <- err
+	}()
+	for n := int64(0); n == 0; n, _ = barrier.NumberWaiting(ctx) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := barrier.Reset(ctx); err != nil {
+		t.Fatalf("reset fail, err: %v", err)
+	}
+	if err := <-broken; !errors.Is(err, ErrBarrierBroken) {
+		t.Fatalf("expected ErrBarrierBroken, got %v", err)
+	}
+
+	// 连续重置后，等待方仍能检查到所在代被重置，正常放行的代不受影响
+	generation, _ := rdb.HGet(ctx, "shards", "generation").Int64()
+	barrier.Reset(ctx)
+	barrier.Reset(ctx)
+	for _, g := range []int64{generation, generation + 1} {
+		if err := barrier.broken(ctx, g); !errors.Is(err, ErrBarrierBroken) {
+			t.Fatalf("expected generation %d broken, got %v", g, err)
+		}
+	}
+	if err := barrier.broken(ctx, 0); err != nil {
+		t.Fatalf("expected tripped generation not broken, got %v", err)
+	}
+
+	for _, parties := range []int64{0, -1} {
+		if _, err := NewRCyclicBarrier(rdb, "shards", parties); !errors.Is(err, ErrInvalidParties) {
+			t.Fatalf("expected ErrInvalidParties for %d, got %v", parties, err)
+		}
+	}
+}
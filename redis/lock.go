@@ -1,12 +1,9 @@
 package redisson
 
 import (
-	"errors"
-	"fmt"
-	"github.com/chenpeicheng3804/go-utils/util"
+	"context"
 	"github.com/go-basic/uuid"
-	"github.com/go-redis/redis"
-	"github.com/sirupsen/logrus"
+	"github.com/redis/go-redis/v9"
 	"sync"
 	"time"
 )
@@ -23,6 +20,7 @@ var rLockMsg = map[int64]string{
 	0:  "锁被占用",
 	-1: "加锁成功",
 	-2: "可重入锁",
+	-3: "读锁不允许升级为写锁",
 }
 
 // rLockScript ...
@@ -81,54 +79,126 @@ end;
 `)
 
 // rLock ...
-var rLock = &RLock{
-	once: &sync.Once{},
-}
+var rLock = &RLock{}
 
 // RLock ...
 type RLock struct {
-	once    *sync.Once
-	mutex   *sync.Mutex
 	rdb     redis.Cmdable
-	name    string
-	clintId string
+	owner   string
+	logger  Logger
+	metrics *LockMetrics
+}
+
+// LockHandle 单次加锁成功后返回的锁句柄，持有锁名与持有者标识，可在协程间传递
+type LockHandle struct {
+	rLock      *RLock
+	name       string
+	token      string
+	expiration time.Duration
+	validUntil time.Time
+	acquiredAt time.Time
+	metrics    *LockMetrics
+	release    releaseFunc
+	renewal    renewFunc
+	mu         sync.Mutex
+	dog        *watchdog
 }
 
+// releaseFunc 执行解锁脚本，返回值含义同 rUnlockMsg
+type releaseFunc func(ctx context.Context, h *LockHandle) (interface{}, error)
+
+// renewFunc 执行续期脚本，返回0表示锁已不属于当前持有者
+type renewFunc func(ctx context.Context, h *LockHandle) (interface{}, error)
+
 // NewRLock ...
-func NewRLock(rdb redis.Cmdable) *RLock {
-	if _, err := rdb.Ping().Result(); err != nil {
+func NewRLock(ctx context.Context, rdb redis.Cmdable) *RLock {
+	if _, err := rdb.Ping(ctx).Result(); err != nil {
 		return nil
 	}
 
-	rLock = &RLock{
-		mutex:   &sync.Mutex{},
-		rdb:     rdb,
-		clintId: uuid.New(),
+	rLock = newRLock(rdb)
+	return rLock
+}
+
+// newRLock ...
+func newRLock(rdb redis.Cmdable) *RLock {
+	return &RLock{
+		rdb:    rdb,
+		logger: defaultLogger,
 	}
+}
 
-	return rLock
+// NewOwnerToken 生成持有者标识
+func NewOwnerToken() string {
+	return uuid.New()
 }
 
-// uniqueId ...
-func (rLock *RLock) uniqueId() string {
-	gid := util.GetGoroutineID()
-	return fmt.Sprintf("%s-%d", rLock.clintId, gid)
+// WithOwner 返回绑定持有者标识的RLock副本，使用相同持有者标识加锁即为锁重入
+func (rLock *RLock) WithOwner(owner string) *RLock {
+	if rLock == nil {
+		return nil
+	}
+
+	c := *rLock
+	c.owner = owner
+	return &c
 }
 
-// buildLockArgs ...
-func (rLock *RLock) buildLockArgs(args ...time.Duration) time.Duration {
+// WithLogger 返回使用指定日志的RLock副本，传入 nil 时不输出日志
+func (rLock *RLock) WithLogger(logger Logger) *RLock {
+	if rLock == nil {
+		return nil
+	}
+	if logger == nil {
+		logger = nopLogger{}
+	}
+
+	c := *rLock
+	c.logger = logger
+	return &c
+}
+
+// WithMetrics 返回记录prometheus指标的RLock副本，传入 nil 时不记录
+func (rLock *RLock) WithMetrics(metrics *LockMetrics) *RLock {
+	if rLock == nil {
+		return nil
+	}
+
+	c := *rLock
+	c.metrics = metrics
+	return &c
+}
+
+// Owner 返回绑定的持有者标识，未绑定时返回空字符串
+func (rLock *RLock) Owner() string {
+	return rLock.owner
+}
+
+// token 未绑定持有者标识时每次加锁生成新的标识
+func (rLock *RLock) token() string {
+	if rLock.owner != "" {
+		return rLock.owner
+	}
+	return NewOwnerToken()
+}
+
+// buildLockArgs 返回毫秒数表示的过期时间，过期时间小于1ms时返回 ErrInvalidExpiration
+func (rLock *RLock) buildLockArgs(args ...time.Duration) (time.Duration, error) {
 	expiration := LockExpiration
 
 	if len(args) == 1 {
 		expiration = args[0]
 	}
 
+	if expiration < time.Millisecond {
+		return 0, ErrInvalidExpiration
+	}
 	expiration = expiration / 1000 / 1000
-	return expiration
+	return expiration, nil
 }
 
-// buildTryLockArgs ...
-func (rLock *RLock) buildTryLockArgs(args ...time.Duration) (time.Duration, time.Duration) {
+// buildTryLockArgs 同 buildLockArgs，另返回等待超时时间
+func (rLock *RLock) buildTryLockArgs(args ...time.Duration) (time.Duration, time.Duration, error) {
 	expiration := LockExpiration
 	timeout := LockTimeout
 
@@ -141,116 +211,202 @@ func (rLock *RLock) buildTryLockArgs(args ...time.Duration) (time.Duration, time
 		timeout = args[1]
 	}
 
+	if expiration < time.Millisecond {
+		return 0, 0, ErrInvalidExpiration
+	}
 	expiration = expiration / 1000 / 1000
-	return expiration, timeout
+	return expiration, timeout, nil
 }
 
-// goTryLock ...
-func (rLock *RLock) goTryLock(key, uniqueId string, expiration, timeout time.Duration) error {
-	ch := make(chan bool)
-
-	go func() {
-		for {
-			if _, ok := <-ch; !ok {
-				break
-			}
-
-			ret, err := rLockScript.Run(rLock.rdb, []string{key}, expiration, uniqueId).Result()
-			if err == nil && ret.(int64) <= 0 {
-				ch <- true
-				break
-			}
+// waitLock 订阅锁释放频道，收到释放通知或锁剩余过期时间耗尽时重试加锁，直到超时或 ctx 取消
+func (rLock *RLock) waitLock(ctx context.Context, name, token string, expiration, timeout time.Duration) error {
+	return waitAcquire(ctx, rLock.rdb, releaseChannel(name), timeout, 0, func() (int64, error) {
+		ret, err := rLockScript.Run(ctx, rLock.rdb, []string{name}, int64(expiration), token).Result()
+		if err != nil {
+			return 0, err
 		}
-	}()
+		return ret.(int64), nil
+	})
+}
 
-	select {
-	case <-ch:
-		return nil
-	case <-time.After(timeout):
-		close(ch)
-		return errors.New("timeout")
+// waitAcquire 订阅频道后循环执行 acquire，返回值<=0表示加锁成功，>0为需等待的毫秒数
+// 收到通知或等待时间耗尽(maxWait>0时不超过maxWait)后重试，直到超时或 ctx 取消
+func waitAcquire(ctx context.Context, rdb redis.Cmdable, channel string, timeout, maxWait time.Duration, acquire func() (int64, error)) error {
+	deadline := time.Now().Add(timeout)
+
+	release, closeFn := subscribe(ctx, rdb, channel)
+	defer closeFn()
+
+	for {
+		// 订阅完成后再尝试一次，避免错过订阅前的释放通知
+		ret, err := acquire()
+		if err != nil {
+			return err
+		}
+		if ret <= 0 {
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return ErrTimeout
+		}
+		wait := time.Duration(ret) * time.Millisecond
+		if maxWait > 0 && wait > maxWait {
+			wait = maxWait
+		}
+		if wait > remaining {
+			wait = remaining
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-release:
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		timer.Stop()
 	}
 }
 
 // printLog ...
-func (rLock *RLock) printLog(name string, ret interface{}) {
+func (rLock *RLock) printLog(name, token string, ret interface{}) {
 	index := ret.(int64)
 	if index > 0 {
 		index = 0
 	}
-	logrus.Infof("lock name=%s field=%s code=%v msg=%s", name, rLock.uniqueId(), ret, rLockMsg[index])
+	rLock.logger.Printf("lock name=%s field=%s code=%v msg=%s", name, token, ret, rLockMsg[index])
 }
 
-// Lock ...
-func (rLock *RLock) Lock(name string, args ...time.Duration) error {
-	if rLock == nil {
-		return errors.New("redis conn err")
+// newHandle ...
+func (rLock *RLock) newHandle(name, token string, expiration time.Duration) *LockHandle {
+	now := time.Now()
+	return &LockHandle{
+		rLock:      rLock,
+		name:       name,
+		token:      token,
+		expiration: expiration * time.Millisecond,
+		validUntil: now.Add(expiration * time.Millisecond),
+		acquiredAt: now,
+		metrics:    rLock.metrics,
 	}
+}
 
-	expiration := rLock.buildLockArgs(args...)
+// Lock 尝试加锁一次，锁被占用时返回 *LockHeldError
+func (rLock *RLock) Lock(ctx context.Context, name string, args ...time.Duration) (h *LockHandle, err error) {
+	if rLock == nil {
+		return nil, ErrNotConnected
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	defer func() { rLock.metrics.observeAcquire(name, start, err) }()
 
-	rLock.mutex.Lock()
-	defer rLock.mutex.Unlock()
+	expiration, err := rLock.buildLockArgs(args...)
+	if err != nil {
+		return nil, err
+	}
 
-	ret, err := rLockScript.Run(rLock.rdb, []string{name}, int64(expiration), rLock.uniqueId()).Result()
+	token := rLock.token()
+	ret, err := rLockScript.Run(ctx, rLock.rdb, []string{name}, int64(expiration), token).Result()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	rLock.printLog(name, ret)
+	rLock.printLog(name, token, ret)
 	if ret.(int64) > 0 {
-		return errors.New(fmt.Sprintf("[%v]lock fail", ret))
+		return nil, lockHeld(name, ret.(int64))
 	}
 
-	rLock.name = name
-	return nil
+	return rLock.newHandle(name, token, expiration), nil
 }
 
-// TryLock ...
-func (rLock *RLock) TryLock(name string, args ...time.Duration) error {
+// TryLock 锁被占用时等待释放后重试，直到超时返回 ErrTimeout 或 ctx 取消
+func (rLock *RLock) TryLock(ctx context.Context, name string, args ...time.Duration) (h *LockHandle, err error) {
 	if rLock == nil {
-		return errors.New("redis conn err")
+		return nil, ErrNotConnected
 	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	defer func() { rLock.metrics.observeAcquire(name, start, err) }()
 
-	expiration, timeout := rLock.buildTryLockArgs(args...)
-
-	rLock.mutex.Lock()
-	defer rLock.mutex.Unlock()
+	expiration, timeout, err := rLock.buildTryLockArgs(args...)
+	if err != nil {
+		return nil, err
+	}
 
-	uniqueId := rLock.uniqueId()
-	ret, err := rLockScript.Run(rLock.rdb, []string{name}, int64(expiration), rLock.uniqueId()).Result()
+	token := rLock.token()
+	ret, err := rLockScript.Run(ctx, rLock.rdb, []string{name}, int64(expiration), token).Result()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	rLock.printLog(name, ret)
+	rLock.printLog(name, token, ret)
 	if ret.(int64) > 0 {
-		// 超时时间内，不断尝试加锁
-		return rLock.goTryLock(name, uniqueId, expiration, timeout)
+		// 超时时间内，等待锁释放后重试加锁
+		if err = rLock.waitLock(ctx, name, token, expiration, timeout); err != nil {
+			return nil, err
+		}
 	}
 
-	rLock.name = name
-	return nil
+	return rLock.newHandle(name, token, expiration), nil
+}
+
+// Name 锁名
+func (h *LockHandle) Name() string {
+	return h.name
+}
+
+// Token 持有者标识
+func (h *LockHandle) Token() string {
+	return h.token
 }
 
-// UnLock ...
-func (rLock *RLock) UnLock() error {
-	rLock.mutex.Lock()
-	defer rLock.mutex.Unlock()
+// ValidUntil 加锁时估算的锁过期时间，看门狗续期不会更新该时间
+func (h *LockHandle) ValidUntil() time.Time {
+	return h.validUntil
+}
+
+// Unlock 释放本次加锁，重入锁需每个句柄各自释放一次
+// 锁已过期返回 ErrLockNotFound，锁被其他持有者占用返回 ErrNotOwner
+func (h *LockHandle) Unlock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	h.StopWatchdog()
 
-	expiration := int64(LockExpiration) / 1000 / 1000
-	ret, err := rUnlockScript.Run(rLock.rdb, []string{rLock.name, LockReleaseChannel}, LockReleaseFlag, expiration, rLock.uniqueId()).Result()
+	release := h.release
+	if release == nil {
+		release = releaseLock
+	}
+	ret, err := release(ctx, h)
 	if err != nil {
-		logrus.Error(err)
+		h.rLock.logger.Printf("unlock name=%s field=%s err=%v", h.name, h.token, err)
 		return err
 	}
 
-	logrus.Infof("unlock name=%s field=%s code=%v msg=%s", rLock.name, rLock.uniqueId(), ret, rUnlockMsg[ret.(int64)])
-	return nil
+	h.rLock.logger.Printf("unlock name=%s field=%s code=%v msg=%s", h.name, h.token, ret, rUnlockMsg[ret.(int64)])
+	// 重入锁部分释放(3)时锁仍被持有，只在完全释放(4)时记录持有时间
+	if err = unlockErr(ret.(int64)); err == nil && ret.(int64) == 4 {
+		h.metrics.observeHold(h.name, h.acquiredAt)
+	}
+	return err
+}
+
+// releaseLock 释放互斥锁
+func releaseLock(ctx context.Context, h *LockHandle) (interface{}, error) {
+	expiration := int64(h.expiration / time.Millisecond)
+	return rUnlockScript.Run(ctx, h.rLock.rdb, []string{h.name, releaseChannel(h.name)}, LockReleaseFlag, expiration, h.token).Result()
 }
 
 // NextId 全局唯一id生成
-func (rLock *RLock) NextId(key string) int64 {
+func (rLock *RLock) NextId(ctx context.Context, key string) int64 {
 	// 1.生成时间戳
 	timeUnix := time.Now().Unix()
 	// 2.生成序列号
@@ -261,7 +417,7 @@ func (rLock *RLock) NextId(key string) int64 {
 	// 2.2.redis key自增长
 	//key拼接 ="icr:"+ "传参key:" + 当前日期
 	// Incr value=自增长
-	IntCmd := rLock.rdb.Incr(NextidKey + key + date)
+	IntCmd := rLock.rdb.Incr(ctx, NextidKey+key+date)
 	if IntCmd.Err() != nil {
 		return 0
 	}
@@ -1,54 +1,226 @@
 package redisson
 
 import (
-	"github.com/go-redis/redis"
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
 	"sync"
 	"testing"
+	"time"
 )
 
 // TestLock ...
 func TestLock(t *testing.T) {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     "127.0.0.1:6379",
-		Password: "demo",
-		DB:       0,
-	})
+	rdb := redistest.Run(t).Client()
+	ctx := context.Background()
 	var wg sync.WaitGroup
-	rLock := NewRLock(rdb)
-	//defer rLock.UnLock()
+	rLock := NewRLock(ctx, rdb).WithOwner(NewOwnerToken())
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		err := rLock.Lock("myLock")
+		l1, err := rLock.Lock(ctx, "myLock")
 		if err != nil {
 			t.Errorf("lock fail, err: %v", err)
+			return
 		}
+		defer l1.Unlock(ctx)
 
-		err = rLock.Lock("myLock")
+		l2, err := rLock.Lock(ctx, "myLock")
 		if err != nil {
 			t.Errorf("lock fail, err: %v", err)
+			return
 		}
-		defer rLock.UnLock()
-		defer rLock.UnLock()
+		defer l2.Unlock(ctx)
 	}()
 	wg.Wait()
 	t.Log("lock success")
 
 }
 
+// TestLockHandle ...
+func TestLockHandle(t *testing.T) {
+	rdb := redistest.Run(t).Client()
+	ctx := context.Background()
+	rLock := NewRLock(ctx, rdb)
+
+	// 不同锁名可同时持有
+	a, err := rLock.Lock(ctx, "myLockA")
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+	b, err := rLock.Lock(ctx, "myLockB")
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+
+	// 未绑定持有者标识时不可重入
+	if _, err = rLock.Lock(ctx, "myLockA"); err == nil {
+		t.Errorf("expected lock fail without owner token")
+	}
+
+	// 句柄可在其他协程中释放
+	done := make(chan error)
+	go func() {
+		done <- a.Unlock(ctx)
+	}()
+	if err = <-done; err != nil {
+		t.Errorf("unlock fail, err: %v", err)
+	}
+	if err = b.Unlock(ctx); err != nil {
+		t.Errorf("unlock fail, err: %v", err)
+	}
+	if n := rdb.Exists(ctx, "myLockA", "myLockB").Val(); n != 0 {
+		t.Errorf("expected locks released, got %d", n)
+	}
+}
+
+// TestLockExpiration ...
+func TestLockExpiration(t *testing.T) {
+	s := redistest.Run(t, redistest.WithFrozenClock())
+	rdb := s.Client()
+	ctx := context.Background()
+	rLock := NewRLock(ctx, rdb)
+
+	l, err := rLock.Lock(ctx, "myExpireLock", time.Second)
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+
+	// 过期前其他持有者加锁失败
+	s.Advance(999 * time.Millisecond)
+	if _, err = rLock.Lock(ctx, "myExpireLock"); !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("expected ErrLockHeld, got %v", err)
+	}
+
+	// 过期后锁自动释放，原持有者不能解锁新持有者的锁
+	s.Advance(time.Millisecond)
+	other, err := rLock.Lock(ctx, "myExpireLock")
+	if err != nil {
+		t.Fatalf("expected expired lock acquired, err: %v", err)
+	}
+	if err = l.Unlock(ctx); !errors.Is(err, ErrNotOwner) {
+		t.Fatalf("expected ErrNotOwner, got %v", err)
+	}
+	if err = other.Unlock(ctx); err != nil {
+		t.Fatalf("unlock fail, err: %v", err)
+	}
+}
+
 // TestTryLock ...
 func TestTryLock(t *testing.T) {
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     "127.0.0.1:6379",
-		Password: "demo",
-		DB:       0,
-	})
+	rdb := redistest.Run(t).Client()
+	ctx := context.Background()
 
-	rLock := NewRLock(rdb)
-	err := rLock.TryLock("myTryLock")
+	rLock := NewRLock(ctx, rdb)
+	l, err := rLock.TryLock(context.Background(), "myTryLock")
 	if err != nil {
-		t.Errorf("lock fail, err: %v", err)
+		t.Fatalf("lock fail, err: %v", err)
 	}
+	defer l.Unlock(context.Background())
 	t.Log("lock success")
 }
+
+// TestTryLockWaitRelease ...
+func TestTryLockWaitRelease(t *testing.T) {
+	rdb := redistest.Run(t).Client()
+	ctx := context.Background()
+	rLock := NewRLock(ctx, rdb)
+
+	holder, err := rLock.Lock(ctx, "myWaitLock")
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		holder.Unlock(ctx)
+	}()
+
+	// 锁过期时间为30s，收到释放通知后应立即获得锁
+	start := time.Now()
+	l, err := rLock.TryLock(ctx, "myWaitLock", LockExpiration, 3*time.Second)
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+	defer l.Unlock(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected wake up on release, waited %v", elapsed)
+	}
+}
+
+// TestTryLockCancel ...
+func TestTryLockCancel(t *testing.T) {
+	rdb := redistest.Run(t).Client()
+	ctx := context.Background()
+	rLock := NewRLock(ctx, rdb)
+
+	holder, err := rLock.Lock(context.Background(), "myCancelLock")
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+	defer holder.Unlock(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err = rLock.TryLock(ctx, "myCancelLock", LockExpiration, 3*time.Second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context deadline exceeded, got %v", err)
+	}
+}
+
+// TestLockErrors ...
+func TestLockErrors(t *testing.T) {
+	rdb := redistest.Run(t).Client()
+	ctx := context.Background()
+	rLock := NewRLock(ctx, rdb)
+
+	l, err := rLock.Lock(ctx, "myErrLock")
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+
+	// 锁被占用：可取出剩余过期时间
+	_, err = rLock.Lock(ctx, "myErrLock")
+	var held *LockHeldError
+	if !errors.Is(err, ErrLockHeld) || !errors.As(err, &held) || held.TTL <= 0 {
+		t.Fatalf("expected ErrLockHeld with ttl, got %v", err)
+	}
+	if _, err = rLock.TryLock(ctx, "myErrLock", LockExpiration, 100*time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+
+	// 他人持有锁时解锁
+	other := &LockHandle{rLock: rLock, name: "myErrLock", token: NewOwnerToken(), expiration: LockExpiration}
+	if err = other.Unlock(ctx); !errors.Is(err, ErrNotOwner) {
+		t.Fatalf("expected ErrNotOwner, got %v", err)
+	}
+
+	if err = l.Unlock(ctx); err != nil {
+		t.Fatalf("unlock fail, err: %v", err)
+	}
+	if err = l.Unlock(ctx); !errors.Is(err, ErrLockNotFound) {
+		t.Fatalf("expected ErrLockNotFound, got %v", err)
+	}
+
+	// 已取消的 ctx 不发起请求
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = rLock.Lock(canceled, "myErrLock"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+
+	// 过期时间小于1ms时看门狗无法计算续期间隔
+	for _, expiration := range []time.Duration{-time.Second, 0, time.Microsecond} {
+		if _, err = rLock.Lock(ctx, "myErrLock", expiration); !errors.Is(err, ErrInvalidExpiration) {
+			t.Fatalf("expected ErrInvalidExpiration for %v, got %v", expiration, err)
+		}
+		if _, err = rLock.TryLock(ctx, "myErrLock", expiration, time.Second); !errors.Is(err, ErrInvalidExpiration) {
+			t.Fatalf("expected ErrInvalidExpiration for %v, got %v", expiration, err)
+		}
+	}
+	h, err := rLock.Lock(ctx, "myErrLock", time.Millisecond)
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+	h.StartWatchdog(ctx)
+	h.StopWatchdog()
+}
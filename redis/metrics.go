@@ -0,0 +1,104 @@
+package redisson
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// LockMetrics 锁的prometheus指标，实现 prometheus.Collector，需自行注册
+//
+//	metrics := redisson.NewLockMetrics("app", nil)
+//	prometheus.MustRegister(metrics)
+//	rLock := redisson.NewRLock(ctx, rdb).WithMetrics(metrics)
+type LockMetrics struct {
+	label        func(name string) string
+	acquisitions *prometheus.CounterVec
+	contentions  *prometheus.CounterVec
+	wait         *prometheus.HistogramVec
+	hold         *prometheus.HistogramVec
+}
+
+// NewLockMetrics label 将锁名映射为指标的 lock 标签，锁名含业务ID时应归并以控制标签基数，nil 时直接使用锁名
+func NewLockMetrics(namespace string, label func(name string) string) *LockMetrics {
+	if label == nil {
+		label = func(name string) string { return name }
+	}
+
+	return &LockMetrics{
+		label: label,
+		acquisitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "lock",
+			Name:      "acquisitions_total",
+			Help:      "Number of successful lock acquisitions.",
+		}, []string{"lock"}),
+		contentions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "lock",
+			Name:      "contention_failures_total",
+			Help:      "Number of lock acquisitions failed because of contention.",
+		}, []string{"lock", "reason"}),
+		wait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "lock",
+			Name:      "wait_seconds",
+			Help:      "Time spent acquiring a lock, including failed attempts.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 8),
+		}, []string{"lock"}),
+		hold: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "lock",
+			Name:      "hold_seconds",
+			Help:      "Time a lock was held before being unlocked.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+		}, []string{"lock"}),
+	}
+}
+
+// Describe ...
+func (m *LockMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.acquisitions.Describe(ch)
+	m.contentions.Describe(ch)
+	m.wait.Describe(ch)
+	m.hold.Describe(ch)
+}
+
+// Collect ...
+func (m *LockMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.acquisitions.Collect(ch)
+	m.contentions.Collect(ch)
+	m.wait.Collect(ch)
+	m.hold.Collect(ch)
+}
+
+// observeAcquire 记录加锁结果，非竞争导致的错误(如 ctx 取消、网络错误)只记录等待时间
+func (m *LockMetrics) observeAcquire(name string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+
+	label := m.label(name)
+	m.wait.WithLabelValues(label).Observe(time.Since(start).Seconds())
+
+	switch {
+	case err == nil:
+		m.acquisitions.WithLabelValues(label).Inc()
+	case errors.Is(err, ErrLockHeld):
+		m.contentions.WithLabelValues(label, "held").Inc()
+	case errors.Is(err, ErrTimeout):
+		m.contentions.WithLabelValues(label, "timeout").Inc()
+	case errors.Is(err, ErrLockUpgrade):
+		m.contentions.WithLabelValues(label, "upgrade").Inc()
+	case errors.Is(err, ErrNoQuorum):
+		m.contentions.WithLabelValues(label, "no_quorum").Inc()
+	}
+}
+
+// observeHold 记录持有时间
+func (m *LockMetrics) observeHold(name string, acquiredAt time.Time) {
+	if m == nil {
+		return
+	}
+	m.hold.WithLabelValues(m.label(name)).Observe(time.Since(acquiredAt).Seconds())
+}
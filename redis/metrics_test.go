@@ -0,0 +1,85 @@
+package redisson
+
+import (
+	"context"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLockMetrics ...
+func TestLockMetrics(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx := context.Background()
+
+	// 按锁名前缀归并标签
+	metrics := NewLockMetrics("test", func(name string) string {
+		return strings.SplitN(name, ":", 2)[0]
+	})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics)
+
+	rLock := NewRLock(ctx, rdb).WithMetrics(metrics)
+	h, err := rLock.Lock(ctx, "order:1")
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+	if _, err = rLock.Lock(ctx, "order:1"); err == nil {
+		t.Fatalf("expected lock held")
+	}
+	if _, err = rLock.TryLock(ctx, "order:1", time.Second, 50*time.Millisecond); err == nil {
+		t.Fatalf("expected timeout")
+	}
+	time.Sleep(10 * time.Millisecond)
+	h.Unlock(ctx)
+
+	NewRFairLock(ctx, rdb).WithMetrics(metrics).Lock(ctx, "order:2")
+
+	// 重入锁部分释放时不记录持有时间
+	reentrant := rLock.WithOwner("reentrant")
+	outer, _ := reentrant.Lock(ctx, "order:3")
+	inner, _ := reentrant.Lock(ctx, "order:3")
+	if err = inner.Unlock(ctx); err != nil {
+		t.Fatalf("unlock inner fail, err: %v", err)
+	}
+	if n := holdSamples(t, registry); n != 1 {
+		t.Fatalf("expected 1 hold sample after partial unlock, got %d", n)
+	}
+	if err = outer.Unlock(ctx); err != nil {
+		t.Fatalf("unlock outer fail, err: %v", err)
+	}
+	if n := holdSamples(t, registry); n != 2 {
+		t.Fatalf("expected 2 hold samples, got %d", n)
+	}
+
+	if n := testutil.ToFloat64(metrics.acquisitions.WithLabelValues("order")); n != 4 {
+		t.Fatalf("expected 4 acquisitions, got %v", n)
+	}
+	if n := testutil.ToFloat64(metrics.contentions.WithLabelValues("order", "held")); n != 1 {
+		t.Fatalf("expected 1 held, got %v", n)
+	}
+	if n := testutil.ToFloat64(metrics.contentions.WithLabelValues("order", "timeout")); n != 1 {
+		t.Fatalf("expected 1 timeout, got %v", n)
+	}
+	if n := testutil.CollectAndCount(metrics, "test_lock_hold_seconds", "test_lock_wait_seconds"); n != 2 {
+		t.Fatalf("expected hold and wait histograms, got %d", n)
+	}
+}
+
+// holdSamples 持有时间直方图的样本数
+func holdSamples(t *testing.T, registry *prometheus.Registry) uint64 {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gather fail, err: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == "test_lock_hold_seconds" {
+			return family.GetMetric()[0].GetHistogram().GetSampleCount()
+		}
+	}
+	return 0
+}
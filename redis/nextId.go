@@ -1,6 +1,8 @@
 package redisson
 
 import (
+	"context"
+	"github.com/redis/go-redis/v9"
 	"time"
 )
 
@@ -15,7 +17,12 @@ const (
 
 // NextId
 // 基于redis生成唯一id
-func (rdb *Rdb) NextId(key string) int64 {
+func (rdb *Rdb) NextId(ctx context.Context, key string) int64 {
+	return NextId(ctx, rdb.Client, key)
+}
+
+// NextId 基于redis生成唯一id，rdb 可以是单节点、哨兵或集群客户端
+func NextId(ctx context.Context, rdb redis.Cmdable, key string) int64 {
 	// 1.生成时间戳
 	timeUnix := time.Now().Unix()
 	// 2.生成序列号
@@ -26,7 +33,7 @@ func (rdb *Rdb) NextId(key string) int64 {
 	// 2.2.redis key自增长
 	//key拼接 ="icr:"+ "传参key:" + 当前日期
 	// Incr value=自增长
-	IntCmd := rdb.Client.Incr(NextidKey + key + date)
+	IntCmd := rdb.Incr(ctx, NextidKey+key+date)
 	if IntCmd.Err() != nil {
 		return 0
 	}
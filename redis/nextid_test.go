@@ -1,14 +1,18 @@
 package redisson
 
 import (
+	"context"
 	"fmt"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
 	"sync"
 	"testing"
 )
 
 func TestNextId(t *testing.T) {
+	s := redistest.Run(t, redistest.WithPassword("demo"))
+	ctx := context.Background()
 	rdb := NewClient(
-		"127.0.0.1:6379",
+		s.Addr(),
 		"demo",
 		0,
 	)
@@ -18,7 +22,7 @@ func TestNextId(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			fmt.Println(rdb.NextId("NextId"))
+			fmt.Println(rdb.NextId(ctx, "NextId"))
 		}()
 
 	}
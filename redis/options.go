@@ -0,0 +1,115 @@
+package redisson
+
+import (
+	"crypto/tls"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// ErrNoAddrs 未配置redis地址
+var ErrNoAddrs = errors.New("redis addrs required")
+
+// Options redis客户端配置
+// 集群模式下锁名需带 hash tag(如 "{order}:lock")，保证锁及其关联的 key、channel 落在同一个 slot
+type Options struct {
+	// Addrs 单节点模式取第一个地址；哨兵模式为哨兵地址；集群模式为种子节点地址
+	Addrs []string
+	// MasterName 非空时使用哨兵模式
+	MasterName string
+	// Cluster 使用集群模式
+	Cluster bool
+
+	Password string
+	// DB 集群模式不支持选择DB
+	DB int
+	// Protocol RESP协议版本，2 或 3，默认3
+	Protocol int
+
+	// TLSConfig 非空时使用TLS连接
+	TLSConfig *tls.Config
+
+	// MaxRetries 命令失败重试次数，默认不重试
+	MaxRetries int
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// PoolSize 连接池大小，集群模式为每个节点的连接池大小
+	PoolSize     int
+	MinIdleConns int
+	PoolTimeout  time.Duration
+	IdleTimeout  time.Duration
+	MaxConnAge   time.Duration
+}
+
+// maxRetries go-redis v9 中0为默认重试3次，-1为不重试，此处保持0为不重试
+func (opt *Options) maxRetries() int {
+	if opt.MaxRetries == 0 {
+		return -1
+	}
+	return opt.MaxRetries
+}
+
+// NewUniversalClient 按配置创建单节点、哨兵或集群客户端
+// 返回值可直接用于 NewRLock、NextId 等接受 redis.Cmdable 的方法
+func NewUniversalClient(opt *Options) (redis.UniversalClient, error) {
+	if len(opt.Addrs) == 0 {
+		return nil, ErrNoAddrs
+	}
+
+	switch {
+	case opt.MasterName != "":
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:      opt.MasterName,
+			SentinelAddrs:   opt.Addrs,
+			Password:        opt.Password,
+			Protocol:        opt.Protocol,
+			DB:              opt.DB,
+			MaxRetries:      opt.maxRetries(),
+			DialTimeout:     opt.DialTimeout,
+			ReadTimeout:     opt.ReadTimeout,
+			WriteTimeout:    opt.WriteTimeout,
+			PoolSize:        opt.PoolSize,
+			MinIdleConns:    opt.MinIdleConns,
+			PoolTimeout:     opt.PoolTimeout,
+			ConnMaxIdleTime: opt.IdleTimeout,
+			ConnMaxLifetime: opt.MaxConnAge,
+			TLSConfig:       opt.TLSConfig,
+		}), nil
+	case opt.Cluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:           opt.Addrs,
+			Password:        opt.Password,
+			Protocol:        opt.Protocol,
+			MaxRetries:      opt.maxRetries(),
+			DialTimeout:     opt.DialTimeout,
+			ReadTimeout:     opt.ReadTimeout,
+			WriteTimeout:    opt.WriteTimeout,
+			PoolSize:        opt.PoolSize,
+			MinIdleConns:    opt.MinIdleConns,
+			PoolTimeout:     opt.PoolTimeout,
+			ConnMaxIdleTime: opt.IdleTimeout,
+			ConnMaxLifetime: opt.MaxConnAge,
+			TLSConfig:       opt.TLSConfig,
+		}), nil
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:            opt.Addrs[0],
+			Password:        opt.Password,
+			Protocol:        opt.Protocol,
+			DB:              opt.DB,
+			MaxRetries:      opt.maxRetries(),
+			DialTimeout:     opt.DialTimeout,
+			ReadTimeout:     opt.ReadTimeout,
+			WriteTimeout:    opt.WriteTimeout,
+			PoolSize:        opt.PoolSize,
+			MinIdleConns:    opt.MinIdleConns,
+			PoolTimeout:     opt.PoolTimeout,
+			ConnMaxIdleTime: opt.IdleTimeout,
+			ConnMaxLifetime: opt.MaxConnAge,
+			TLSConfig:       opt.TLSConfig,
+		}), nil
+	}
+}
@@ -0,0 +1,46 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"testing"
+	"time"
+)
+
+// TestNewUniversalClient ...
+func TestNewUniversalClient(t *testing.T) {
+	s := redistest.Run(t, redistest.WithPassword("demo"))
+	ctx := context.Background()
+
+	if _, err := NewUniversalClient(&Options{}); !errors.Is(err, ErrNoAddrs) {
+		t.Fatalf("expected ErrNoAddrs, got %v", err)
+	}
+
+	for name, opt := range map[string]*Options{
+		"standalone": {Addrs: []string{s.Addr()}, Password: "demo", PoolSize: 4, DialTimeout: time.Second},
+		"cluster":    {Addrs: []string{s.Addr()}, Password: "demo", Cluster: true},
+	} {
+		rdb, err := NewUniversalClient(opt)
+		if err != nil {
+			t.Fatalf("%s: new client fail, err: %v", name, err)
+		}
+
+		// 可直接用于锁及唯一id生成
+		rLock := NewRLock(ctx, rdb)
+		if rLock == nil {
+			t.Fatalf("%s: new rlock fail", name)
+		}
+		h, err := rLock.Lock(ctx, "{options}:"+name)
+		if err != nil {
+			t.Fatalf("%s: lock fail, err: %v", name, err)
+		}
+		if err = h.Unlock(ctx); err != nil {
+			t.Fatalf("%s: unlock fail, err: %v", name, err)
+		}
+		if id := NextId(ctx, rdb, name); id == 0 {
+			t.Fatalf("%s: next id fail", name)
+		}
+		rdb.Close()
+	}
+}
@@ -0,0 +1,47 @@
+package redisson
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"strings"
+)
+
+// subscriber 支持订阅的客户端，redis.Client、redis.ClusterClient、redis.Ring 均实现
+type subscriber interface {
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+// hashTag 派生 key 的 hash tag，保证集群模式下与 name 落在同一 slot，多 key 脚本不会 CROSSSLOT
+// name 已含 hash tag 时直接使用 name，否则为 {name}
+func hashTag(name string) string {
+	if start := strings.IndexByte(name, '{'); start >= 0 && strings.IndexByte(name[start+1:], '}') > 0 {
+		return name
+	}
+	return "{" + name + "}"
+}
+
+// releaseChannel 锁释放广播频道，每个锁名独立，只唤醒等待该锁的线程
+// 频道作为脚本 KEYS 传入，同样带 hash tag
+func releaseChannel(name string) string {
+	return LockReleaseChannel + ":" + hashTag(name)
+}
+
+// subscribe 订阅频道，返回通知通道及关闭函数
+// 客户端不支持订阅或订阅失败时返回 nil 通道，调用方退化为按锁剩余过期时间轮询
+func subscribe(ctx context.Context, rdb redis.Cmdable, channel string) (<-chan *redis.Message, func()) {
+	sub, ok := rdb.(subscriber)
+	if !ok {
+		return nil, func() {}
+	}
+
+	pubsub := sub.Subscribe(ctx, channel)
+	// 等待订阅确认，保证之后的发布不会丢失
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, func() {}
+	}
+
+	return pubsub.Channel(), func() {
+		pubsub.Close()
+	}
+}
@@ -0,0 +1,442 @@
+package redisson
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// QueueDefaultGroup 默认消费组
+	QueueDefaultGroup = "default"
+	// QueueMaxAttempts 默认最大处理次数，超过后转入死信队列
+	QueueMaxAttempts = 5
+	// QueueClaimIdle 默认空闲多久的待确认消息视为消费者已失效，由其他消费者认领
+	QueueClaimIdle = time.Minute
+	// QueueBlockTimeout 默认阻塞读取超时时间，同时决定停止消费的响应时间
+	QueueBlockTimeout = time.Second
+	// QueuePollInterval 到期重试及失效消息认领的检查间隔
+	QueuePollInterval = time.Second
+	// QueueRetryBase 默认重试退避基数
+	QueueRetryBase = time.Second
+	// QueueRetryMax 默认最大重试间隔
+	QueueRetryMax = 5 * time.Minute
+)
+
+// rQueueNackScript ...
+var rQueueNackScript = redis.NewScript(`
+local attempts = redis.call('HINCRBY', KEYS[2], ARGV[2], 1);
+
+-- 超过最大处理次数：转入死信队列并确认
+if attempts >= tonumber(ARGV[3]) then
+    redis.call('XADD', KEYS[4], '*', 'id', ARGV[2], 'payload', ARGV[5], 'attempts', attempts, 'error', ARGV[6]);
+    redis.call('XACK', KEYS[1], ARGV[1], ARGV[2]);
+    redis.call('HDEL', KEYS[2], ARGV[2]);
+    redis.call('ZREM', KEYS[3], ARGV[2]);
+    return -1;
+end;
+
+-- 保持待确认状态，到期后重新认领
+redis.call('ZADD', KEYS[3], ARGV[4], ARGV[2]);
+return attempts;
+`)
+
+// rQueueAckScript ...
+var rQueueAckScript = redis.NewScript(`
+redis.call('HDEL', KEYS[2], ARGV[2]);
+redis.call('ZREM', KEYS[3], ARGV[2]);
+return redis.call('XACK', KEYS[1], ARGV[1], ARGV[2]);
+`)
+
+// QueueOptions 队列消费配置，零值字段使用默认值
+type QueueOptions struct {
+	// Group 消费组
+	Group string
+	// Consumer 消费者名称，默认 主机名:随机串
+	Consumer string
+	// Workers 并发处理的协程数
+	Workers int
+	// MaxAttempts 最大处理次数
+	MaxAttempts int
+	// Backoff 第 attempt 次失败后的重试间隔，默认指数退避加随机抖动
+	Backoff func(attempt int) time.Duration
+	// ClaimIdle 待确认消息空闲超过该时间后被认领重新处理
+	// 处理中的消息每隔 ClaimIdle/2 刷新一次空闲时间，消费者与redis连接中断超过 ClaimIdle 时仍可能被重复处理
+	ClaimIdle time.Duration
+	// BlockTimeout 阻塞读取超时时间
+	BlockTimeout time.Duration
+	// MaxLen 入队时近似裁剪stream长度，0 表示不裁剪
+	MaxLen int64
+}
+
+// ExponentialBackoff 指数退避：base*2^(attempt-1)，不超过 max，并加入最多 50% 的随机抖动
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	}
+}
+
+// Job 队列中的任务
+type Job[T any] struct {
+	// ID stream 消息ID
+	ID string
+	// Payload 任务内容
+	Payload T
+	// Attempts 此前已失败的次数
+	Attempts int
+
+	data string
+}
+
+// RQueue 基于 Redis Streams 消费组的可靠任务队列
+// 处理失败的任务保持待确认状态并按退避时间重试，超过最大处理次数后转入死信队列
+type RQueue[T any] struct {
+	rdb  redis.Cmdable
+	name string
+	opts QueueOptions
+}
+
+// NewRQueue name 为stream名称
+func NewRQueue[T any](rdb redis.Cmdable, name string, opts QueueOptions) *RQueue[T] {
+	if opts.Group == "" {
+		opts.Group = QueueDefaultGroup
+	}
+	if opts.Consumer == "" {
+		host, _ := os.Hostname()
+		opts.Consumer = host + ":" + NewOwnerToken()[:8]
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = QueueMaxAttempts
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = ExponentialBackoff(QueueRetryBase, QueueRetryMax)
+	}
+	if opts.ClaimIdle <= 0 {
+		opts.ClaimIdle = QueueClaimIdle
+	}
+	if opts.BlockTimeout <= 0 {
+		opts.BlockTimeout = QueueBlockTimeout
+	}
+
+	return &RQueue[T]{
+		rdb:  rdb,
+		name: name,
+		opts: opts,
+	}
+}
+
+// attemptsKey 记录每条消息的失败次数
+func (q *RQueue[T]) attemptsKey() string {
+	return q.name + ":" + q.opts.Group + ":attempts"
+}
+
+// retryKey 记录每条消息的下次重试时间
+func (q *RQueue[T]) retryKey() string {
+	return q.name + ":" + q.opts.Group + ":retry"
+}
+
+// DeadLetter 死信队列stream名称
+func (q *RQueue[T]) DeadLetter() string {
+	return q.name + ":" + q.opts.Group + ":dead"
+}
+
+// Enqueue 任务入队，返回消息ID
+func (q *RQueue[T]) Enqueue(ctx context.Context, payload T) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return q.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.name,
+		MaxLen: q.opts.MaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"payload": data},
+	}).Result()
+}
+
+// Ack 确认任务处理完成
+func (q *RQueue[T]) Ack(ctx context.Context, job *Job[T]) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return rQueueAckScript.Run(ctx, q.rdb, []string{q.name, q.attemptsKey(), q.retryKey()}, q.opts.Group, job.ID).Err()
+}
+
+// Nack 任务处理失败，按退避时间重试，超过最大处理次数后转入死信队列
+func (q *RQueue[T]) Nack(ctx context.Context, job *Job[T], cause error) error {
+	return q.nack(ctx, job, cause, q.opts.MaxAttempts)
+}
+
+// nack maxAttempts 为 1 时直接转入死信队列
+func (q *RQueue[T]) nack(ctx context.Context, job *Job[T], cause error, maxAttempts int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	msg := ""
+	if cause != nil {
+		msg = cause.Error()
+	}
+	retryAt := time.Now().Add(q.opts.Backoff(job.Attempts+1)).UnixNano() / int64(time.Millisecond)
+	return rQueueNackScript.Run(ctx, q.rdb, []string{q.name, q.attemptsKey(), q.retryKey(), q.DeadLetter()},
+		q.opts.Group, job.ID, maxAttempts, retryAt, job.data, msg).Err()
+}
+
+// Consume 启动 Workers 个协程处理任务，直到 ctx 取消且处理中的任务完成
+// handler 返回 nil 时确认任务，返回错误或 panic 时重试
+func (q *RQueue[T]) Consume(ctx context.Context, handler func(ctx context.Context, job *Job[T]) error) error {
+	err := q.rdb.XGroupCreateMkStream(ctx, q.name, q.opts.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	jobs := make(chan *Job[T])
+	var wg sync.WaitGroup
+	for i := 0; i < q.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				q.handle(ctx, job, handler)
+			}
+		}()
+	}
+
+	var producers sync.WaitGroup
+	producers.Add(2)
+	go func() {
+		defer producers.Done()
+		q.read(ctx, jobs)
+	}()
+	go func() {
+		defer producers.Done()
+		q.reclaim(ctx, jobs)
+	}()
+
+	producers.Wait()
+	close(jobs)
+	wg.Wait()
+	return ctx.Err()
+}
+
+// handle ...
+func (q *RQueue[T]) handle(ctx context.Context, job *Job[T], handler func(ctx context.Context, job *Job[T]) error) {
+	stop := make(chan struct{})
+	defer close(stop)
+	go q.keepClaim(context.WithoutCancel(ctx), job, stop)
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return handler(ctx, job)
+	}()
+
+	// 停止消费时仍需完成确认
+	ctx = context.WithoutCancel(ctx)
+	if err == nil {
+		err = q.Ack(ctx, job)
+	} else {
+		err = q.Nack(ctx, job, err)
+	}
+	if err != nil {
+		defaultLogger.Printf("queue %s job %s ack fail, err: %v", q.name, job.ID, err)
+	}
+}
+
+// keepClaim 处理期间定期刷新消息的空闲时间，避免处理时间超过 ClaimIdle 时被其他消费者认领
+func (q *RQueue[T]) keepClaim(ctx context.Context, job *Job[T], stop <-chan struct{}) {
+	ticker := time.NewTicker(q.opts.ClaimIdle / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		err := q.rdb.XClaimJustID(ctx, &redis.XClaimArgs{
+			Stream:   q.name,
+			Group:    q.opts.Group,
+			Consumer: q.opts.Consumer,
+			Messages: []string{job.ID},
+		}).Err()
+		if err != nil {
+			defaultLogger.Printf("queue %s job %s keep claim fail, err: %v", q.name, job.ID, err)
+		}
+	}
+}
+
+// dispatch ...
+func (q *RQueue[T]) dispatch(ctx context.Context, jobs chan<- *Job[T], messages []redis.XMessage) {
+	for _, msg := range messages {
+		job, err := q.decode(ctx, msg)
+		if err != nil {
+			// 无法解析的消息重试无意义，直接转入死信队列
+			defaultLogger.Printf("queue %s decode job %s fail, err: %v", q.name, msg.ID, err)
+			q.nack(context.WithoutCancel(ctx), job, err, 1)
+			continue
+		}
+
+		select {
+		case jobs <- job:
+		case <-ctx.Done():
+			// 未处理的消息保持待确认状态，由 reclaim 重新认领
+			return
+		}
+	}
+}
+
+// decode ...
+func (q *RQueue[T]) decode(ctx context.Context, msg redis.XMessage) (*Job[T], error) {
+	job := &Job[T]{ID: msg.ID}
+	job.data, _ = msg.Values["payload"].(string)
+	if err := json.Unmarshal([]byte(job.data), &job.Payload); err != nil {
+		return job, err
+	}
+
+	// 读取失败次数出错时按首次处理，最大处理次数仍由 nack 脚本按redis中的计数判断
+	job.Attempts, _ = q.rdb.HGet(ctx, q.attemptsKey(), msg.ID).Int()
+	return job, nil
+}
+
+// read 读取新消息
+func (q *RQueue[T]) read(ctx context.Context, jobs chan<- *Job[T]) {
+	for ctx.Err() == nil {
+		streams, err := q.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    q.opts.Group,
+			Consumer: q.opts.Consumer,
+			Streams:  []string{q.name, ">"},
+			Count:    int64(q.opts.Workers),
+			Block:    q.opts.BlockTimeout,
+		}).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			defaultLogger.Printf("queue %s read fail, err: %v", q.name, err)
+			sleepContext(ctx, q.opts.BlockTimeout)
+			continue
+		}
+
+		for _, stream := range streams {
+			q.dispatch(ctx, jobs, stream.Messages)
+		}
+	}
+}
+
+// reclaim 认领到期重试的消息，及失效消费者空闲超过 ClaimIdle 的消息
+func (q *RQueue[T]) reclaim(ctx context.Context, jobs chan<- *Job[T]) {
+	cursor := "0-0"
+	for sleepContext(ctx, QueuePollInterval) {
+		if err := q.retry(ctx, jobs); err != nil {
+			defaultLogger.Printf("queue %s retry fail, err: %v", q.name, err)
+		}
+
+		next, err := q.autoClaim(ctx, jobs, cursor)
+		if err != nil {
+			defaultLogger.Printf("queue %s claim fail, err: %v", q.name, err)
+			continue
+		}
+		cursor = next
+	}
+}
+
+// retry ...
+func (q *RQueue[T]) retry(ctx context.Context, jobs chan<- *Job[T]) error {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	ids, err := q.rdb.ZRangeByScore(ctx, q.retryKey(), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now, 10),
+		Count: int64(q.opts.Workers),
+	}).Result()
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+
+	// 多个消费者同时重试时，ZREM 成功者认领
+	claimed := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if n, err := q.rdb.ZRem(ctx, q.retryKey(), id).Result(); err == nil && n == 1 {
+			claimed = append(claimed, id)
+		}
+	}
+	if len(claimed) == 0 {
+		return nil
+	}
+
+	messages, err := q.rdb.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   q.name,
+		Group:    q.opts.Group,
+		Consumer: q.opts.Consumer,
+		Messages: claimed,
+	}).Result()
+	if err != nil {
+		return err
+	}
+	q.dispatch(ctx, jobs, messages)
+	return nil
+}
+
+// autoClaim ...
+func (q *RQueue[T]) autoClaim(ctx context.Context, jobs chan<- *Job[T], cursor string) (string, error) {
+	entries, next, err := q.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   q.name,
+		Group:    q.opts.Group,
+		Consumer: q.opts.Consumer,
+		MinIdle:  q.opts.ClaimIdle,
+		Start:    cursor,
+		Count:    int64(q.opts.Workers),
+	}).Result()
+	if err != nil {
+		return cursor, err
+	}
+
+	messages := make([]redis.XMessage, 0, len(entries))
+	for _, msg := range entries {
+		// 等待重试的消息由 retry 按时认领
+		if _, err := q.rdb.ZScore(ctx, q.retryKey(), msg.ID).Result(); err == nil {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	q.dispatch(ctx, jobs, messages)
+	return next, nil
+}
+
+// sleepContext 返回 false 表示 ctx 已取消
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,148 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"testing"
+	"time"
+)
+
+// task ...
+type task struct {
+	Name string `json:"name"`
+}
+
+// TestQueue ...
+func TestQueue(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	q := NewRQueue[task](rdb, "jobs", QueueOptions{
+		Workers:     4,
+		MaxAttempts: 3,
+		Backoff:     func(int) time.Duration { return 10 * time.Millisecond },
+		ClaimIdle:   100 * time.Millisecond,
+	})
+
+	// 模拟已读取消息后失效的消费者
+	if err := rdb.XGroupCreateMkStream(ctx, "jobs", QueueDefaultGroup, "0").Err(); err != nil {
+		t.Fatalf("create group fail, err: %v", err)
+	}
+	q.Enqueue(ctx, task{Name: "orphan"})
+	if err := rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group: QueueDefaultGroup, Consumer: "crashed", Streams: []string{"jobs", ">"}, Count: 1, Block: 10 * time.Millisecond,
+	}).Err(); err != nil {
+		t.Fatalf("read group fail, err: %v", err)
+	}
+
+	for _, name := range []string{"ok", "flaky", "fail"} {
+		if _, err := q.Enqueue(ctx, task{Name: name}); err != nil {
+			t.Fatalf("enqueue fail, err: %v", err)
+		}
+	}
+	// 无法解析的消息
+	rdb.XAdd(ctx, &redis.XAddArgs{Stream: "jobs", Values: map[string]interface{}{"payload": "{"}})
+
+	var (
+		mutex sync.Mutex
+		calls = make(map[string][]int)
+		done  = make(chan struct{})
+	)
+	consumed := make(chan error, 1)
+	go func() {
+		consumed <- q.Consume(ctx, func(ctx context.Context, job *Job[task]) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			calls[job.Payload.Name] = append(calls[job.Payload.Name], job.Attempts)
+			if len(calls["ok"]) == 1 && len(calls["flaky"]) == 2 && len(calls["fail"]) == 3 && len(calls["orphan"]) == 1 {
+				close(done)
+			}
+
+			switch {
+			case job.Payload.Name == "flaky" && job.Attempts == 0:
+				return errors.New("flaky")
+			case job.Payload.Name == "fail":
+				panic("always fail")
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		mutex.Lock()
+		t.Fatalf("timeout waiting jobs, calls: %v", calls)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-consumed; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+
+	if calls["flaky"][1] != 1 || calls["fail"][2] != 2 {
+		t.Fatalf("unexpected attempts %v", calls)
+	}
+
+	// 失败任务及无法解析的消息进入死信队列
+	dead, err := rdb.XRange(context.Background(), q.DeadLetter(), "-", "+").Result()
+	if err != nil || len(dead) != 2 {
+		t.Fatalf("expected 2 dead letters, got %v err: %v", dead, err)
+	}
+	if dead[1].Values["attempts"] != "3" || dead[1].Values["payload"] != `{"name":"fail"}` {
+		t.Fatalf("unexpected dead letter %v", dead[1].Values)
+	}
+
+	// 所有消息均已确认
+	pending, err := rdb.XPending(context.Background(), "jobs", QueueDefaultGroup).Result()
+	if err != nil || pending.Count != 0 {
+		t.Fatalf("expected no pending, got %+v err: %v", pending, err)
+	}
+}
+
+// TestQueueKeepClaim ...
+func TestQueueKeepClaim(t *testing.T) {
+	s := redistest.Run(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := QueueOptions{ClaimIdle: 100 * time.Millisecond}
+	q := NewRQueue[task](s.Client(), "slow", opts)
+	if _, err := q.Enqueue(ctx, task{Name: "slow"}); err != nil {
+		t.Fatalf("enqueue fail, err: %v", err)
+	}
+
+	// 处理时间远超 ClaimIdle，另一消费者错开检查失效消息的时间
+	var (
+		mutex sync.Mutex
+		calls int
+		wg    sync.WaitGroup
+	)
+	handler := func(ctx context.Context, job *Job[task]) error {
+		mutex.Lock()
+		calls++
+		mutex.Unlock()
+		time.Sleep(2 * QueuePollInterval)
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			NewRQueue[task](s.Client(), "slow", opts).Consume(ctx, handler)
+		}()
+		time.Sleep(QueuePollInterval / 2)
+	}
+
+	time.Sleep(QueuePollInterval + 300*time.Millisecond)
+	cancel()
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("expected job handled once, got %d", calls)
+	}
+}
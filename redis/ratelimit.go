@@ -0,0 +1,288 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/go-basic/uuid"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	// TokenBucketPrefix 令牌桶限流前缀
+	TokenBucketPrefix = "rate:tb:"
+	// SlidingWindowPrefix 滑动窗口日志限流前缀
+	SlidingWindowPrefix = "rate:sw:"
+	// GCRAPrefix GCRA限流前缀
+	GCRAPrefix = "rate:gcra:"
+)
+
+// ErrLimitExceeded 单次请求数量超过限流容量，永远无法放行
+var ErrLimitExceeded = errors.New("rate limit exceeded")
+
+// ErrInvalidLimit 限流速率 Rate 需为正数，Period 不能小于1ms
+var ErrInvalidLimit = errors.New("rate must be positive and period at least 1ms")
+
+// ErrInvalidRequests 申请的请求数需为正数
+var ErrInvalidRequests = errors.New("request count must be positive")
+
+// rTokenBucketScript ...
+var rTokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1]);
+local rate = tonumber(ARGV[2]);
+local now = tonumber(ARGV[3]);
+local n = tonumber(ARGV[4]);
+
+-- 按上次请求至今的时间补充令牌，不超过桶容量
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts');
+local tokens = tonumber(state[1]);
+local ts = tonumber(state[2]);
+if tokens == nil then
+    tokens = capacity;
+    ts = now;
+end;
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate);
+
+local allowed = 0;
+local retry = 0;
+if n > capacity then
+    retry = -1;
+elseif tokens >= n then
+    tokens = tokens - n;
+    allowed = 1;
+else
+    retry = math.ceil((n - tokens) / rate);
+end;
+
+-- 令牌补满后 key 自动过期
+local reset = math.ceil((capacity - tokens) / rate);
+redis.call('HMSET', KEYS[1], 'tokens', tokens, 'ts', now);
+redis.call('PEXPIRE', KEYS[1], math.max(reset, 1));
+return {allowed, math.floor(tokens), retry, reset};
+`)
+
+// rSlidingWindowScript ...
+var rSlidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1]);
+local window = tonumber(ARGV[2]);
+local now = tonumber(ARGV[3]);
+local n = tonumber(ARGV[4]);
+
+-- 移除窗口外的请求记录
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window);
+local count = redis.call('ZCARD', KEYS[1]);
+
+if count + n <= limit then
+    for i = 1, n, 1 do
+        redis.call('ZADD', KEYS[1], now, ARGV[5] .. ':' .. i);
+    end;
+    redis.call('PEXPIRE', KEYS[1], window);
+    local first = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES');
+    return {1, limit - count - n, 0, tonumber(first[2]) + window - now};
+end;
+
+-- 需等待最早的 count+n-limit 条记录滑出窗口
+local retry = -1;
+if n <= limit then
+    local index = count + n - limit - 1;
+    local entry = redis.call('ZRANGE', KEYS[1], index, index, 'WITHSCORES');
+    retry = math.max(1, tonumber(entry[2]) + window - now);
+end;
+local reset = 0;
+local first = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES');
+if first[2] ~= nil then
+    reset = tonumber(first[2]) + window - now;
+end;
+return {0, math.max(0, limit - count), retry, reset};
+`)
+
+// rGCRAScript ...
+var rGCRAScript = redis.NewScript(`
+local burst = tonumber(ARGV[1]);
+local interval = tonumber(ARGV[2]);
+local now = tonumber(ARGV[3]);
+local n = tonumber(ARGV[4]);
+
+-- 理论到达时间(TAT)
+local tat = tonumber(redis.call('GET', KEYS[1]));
+if tat == nil then
+    tat = now;
+end;
+tat = math.max(tat, now);
+
+local increment = interval * n;
+local burstOffset = interval * burst;
+local newTat = tat + increment;
+local diff = now - (newTat - burstOffset);
+
+if diff < 0 then
+    local retry = -diff;
+    if increment > burstOffset then
+        retry = -1;
+    end;
+    return {0, 0, math.ceil(retry), math.ceil(tat - now)};
+end;
+
+local reset = newTat - now;
+if reset > 0 then
+    redis.call('SET', KEYS[1], newTat, 'PX', math.ceil(reset));
+end;
+return {1, math.floor(diff / interval), 0, math.ceil(reset)};
+`)
+
+// Limit 限流速率：Period 内允许 Rate 次请求，Burst 为允许的突发请求数(Burst<=0 时等于 Rate)
+// 滑动窗口日志限流忽略 Burst
+type Limit struct {
+	Rate   int64
+	Period time.Duration
+	Burst  int64
+}
+
+// NewLimit 创建限流速率，rate<=0 或 period 小于1ms时返回 ErrInvalidLimit
+func NewLimit(rate int64, period time.Duration, burst int64) (Limit, error) {
+	limit := Limit{Rate: rate, Period: period, Burst: burst}
+	if err := limit.Validate(); err != nil {
+		return Limit{}, err
+	}
+	return limit, nil
+}
+
+// PerSecond 每秒 rate 次
+func PerSecond(rate int64) Limit {
+	return Limit{Rate: rate, Period: time.Second}
+}
+
+// PerMinute 每分钟 rate 次
+func PerMinute(rate int64) Limit {
+	return Limit{Rate: rate, Period: time.Minute}
+}
+
+// Validate 校验限流速率，Rate<=0 或 Period 小于1ms时返回 ErrInvalidLimit
+func (limit Limit) Validate() error {
+	if limit.Rate <= 0 || limit.Period < time.Millisecond {
+		return ErrInvalidLimit
+	}
+	return nil
+}
+
+// burst ...
+func (limit Limit) burst() int64 {
+	if limit.Burst > 0 {
+		return limit.Burst
+	}
+	return limit.Rate
+}
+
+// LimitResult 限流结果
+type LimitResult struct {
+	// Allowed 是否放行
+	Allowed bool
+	// Remaining 剩余可用请求数
+	Remaining int64
+	// RetryAfter 被拒绝时距离可放行的时间，-1 表示请求数超过容量永远无法放行
+	RetryAfter time.Duration
+	// ResetAfter 距离额度完全恢复的时间
+	ResetAfter time.Duration
+}
+
+// RRateLimiter 基于redis的分布式限流
+type RRateLimiter struct {
+	rdb    redis.Cmdable
+	prefix string
+	run    func(ctx context.Context, rdb redis.Cmdable, key string, limit Limit, n, now int64) (interface{}, error)
+}
+
+// NewTokenBucketLimiter 令牌桶限流，桶容量为 Burst，按 Rate/Period 速率补充令牌
+func NewTokenBucketLimiter(rdb redis.Cmdable) *RRateLimiter {
+	return &RRateLimiter{
+		rdb:    rdb,
+		prefix: TokenBucketPrefix,
+		run: func(ctx context.Context, rdb redis.Cmdable, key string, limit Limit, n, now int64) (interface{}, error) {
+			rate := float64(limit.Rate) / float64(limit.Period/time.Millisecond)
+			return rTokenBucketScript.Run(ctx, rdb, []string{key}, limit.burst(), rate, now, n).Result()
+		},
+	}
+}
+
+// NewSlidingWindowLimiter 滑动窗口日志限流，任意 Period 时间窗口内最多 Rate 次请求
+func NewSlidingWindowLimiter(rdb redis.Cmdable) *RRateLimiter {
+	return &RRateLimiter{
+		rdb:    rdb,
+		prefix: SlidingWindowPrefix,
+		run: func(ctx context.Context, rdb redis.Cmdable, key string, limit Limit, n, now int64) (interface{}, error) {
+			return rSlidingWindowScript.Run(ctx, rdb, []string{key}, limit.Rate, int64(limit.Period/time.Millisecond), now, n, uuid.New()).Result()
+		},
+	}
+}
+
+// NewGCRALimiter 通用信元速率算法限流，请求按 Period/Rate 间隔均匀放行，允许 Burst 次突发
+func NewGCRALimiter(rdb redis.Cmdable) *RRateLimiter {
+	return &RRateLimiter{
+		rdb:    rdb,
+		prefix: GCRAPrefix,
+		run: func(ctx context.Context, rdb redis.Cmdable, key string, limit Limit, n, now int64) (interface{}, error) {
+			interval := float64(limit.Period/time.Millisecond) / float64(limit.Rate)
+			return rGCRAScript.Run(ctx, rdb, []string{key}, limit.burst(), interval, now, n).Result()
+		},
+	}
+}
+
+// Allow 申请1次请求额度
+func (l *RRateLimiter) Allow(ctx context.Context, key string, limit Limit) (*LimitResult, error) {
+	return l.AllowN(ctx, key, limit, 1)
+}
+
+// AllowN 申请 n 次请求额度，n<=0 时返回 ErrInvalidRequests，限流速率无效时返回 ErrInvalidLimit
+func (l *RRateLimiter) AllowN(ctx context.Context, key string, limit Limit, n int64) (*LimitResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, ErrInvalidRequests
+	}
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	ret, err := l.run(ctx, l.rdb, l.prefix+key, limit, n, now)
+	if err != nil {
+		return nil, err
+	}
+
+	values := ret.([]interface{})
+	res := &LimitResult{
+		Allowed:    values[0].(int64) == 1,
+		Remaining:  values[1].(int64),
+		RetryAfter: time.Duration(values[2].(int64)) * time.Millisecond,
+		ResetAfter: time.Duration(values[3].(int64)) * time.Millisecond,
+	}
+	if values[2].(int64) < 0 {
+		res.RetryAfter = -1
+	}
+	return res, nil
+}
+
+// Wait 等待直到获得1次请求额度，或 ctx 取消
+func (l *RRateLimiter) Wait(ctx context.Context, key string, limit Limit) error {
+	for {
+		res, err := l.Allow(ctx, key, limit)
+		if err != nil {
+			return err
+		}
+		if res.Allowed {
+			return nil
+		}
+		if res.RetryAfter < 0 {
+			return ErrLimitExceeded
+		}
+
+		timer := time.NewTimer(res.RetryAfter)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
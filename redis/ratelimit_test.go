@@ -0,0 +1,105 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"testing"
+	"time"
+)
+
+// TestRateLimiter ...
+func TestRateLimiter(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx := context.Background()
+
+	limiters := map[string]*RRateLimiter{
+		"token_bucket":   NewTokenBucketLimiter(rdb),
+		"sliding_window": NewSlidingWindowLimiter(rdb),
+		"gcra":           NewGCRALimiter(rdb),
+	}
+	limit := Limit{Rate: 5, Period: time.Second}
+
+	for name, l := range limiters {
+		// 额度内全部放行，剩余额度递减
+		for i := int64(0); i < limit.Rate; i++ {
+			res, err := l.Allow(ctx, "api", limit)
+			if err != nil || !res.Allowed {
+				t.Fatalf("%s: expected allowed at %d, err: %v", name, i, err)
+			}
+			if res.Remaining != limit.Rate-i-1 {
+				t.Fatalf("%s: expected remaining %d, got %d", name, limit.Rate-i-1, res.Remaining)
+			}
+		}
+
+		// 超出额度被拒绝，并给出重试时间
+		res, err := l.Allow(ctx, "api", limit)
+		if err != nil || res.Allowed || res.RetryAfter <= 0 || res.RetryAfter > limit.Period {
+			t.Fatalf("%s: expected rejected with retry after, got %+v err: %v", name, res, err)
+		}
+
+		// 不同 key 独立计数
+		if res, err = l.Allow(ctx, "other", limit); err != nil || !res.Allowed {
+			t.Fatalf("%s: expected other key allowed, err: %v", name, err)
+		}
+
+		// 超过容量的请求永远无法放行
+		if res, err = l.AllowN(ctx, "api", limit, limit.Rate+1); err != nil || res.Allowed || res.RetryAfter != -1 {
+			t.Fatalf("%s: expected never allowed, got %+v err: %v", name, res, err)
+		}
+
+		// Wait 等待额度恢复
+		start := time.Now()
+		if err = l.Wait(ctx, "api", limit); err != nil {
+			t.Fatalf("%s: wait fail, err: %v", name, err)
+		}
+		if time.Since(start) > limit.Period+100*time.Millisecond {
+			t.Fatalf("%s: wait took too long: %v", name, time.Since(start))
+		}
+
+		// 无效的请求数及限流速率
+		for _, n := range []int64{0, -1} {
+			if _, err = l.AllowN(ctx, "api", limit, n); !errors.Is(err, ErrInvalidRequests) {
+				t.Fatalf("%s: expected ErrInvalidRequests for %d, got %v", name, n, err)
+			}
+		}
+		for _, invalid := range []Limit{{}, {Rate: 5}, {Period: time.Second}, {Rate: -1, Period: time.Second}, {Rate: 5, Period: time.Microsecond}} {
+			if _, err = l.Allow(ctx, "api", invalid); !errors.Is(err, ErrInvalidLimit) {
+				t.Fatalf("%s: expected ErrInvalidLimit for %+v, got %v", name, invalid, err)
+			}
+			if err = l.Wait(ctx, "api", invalid); !errors.Is(err, ErrInvalidLimit) {
+				t.Fatalf("%s: expected ErrInvalidLimit for %+v, got %v", name, invalid, err)
+			}
+		}
+
+		// ctx 取消
+		timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		for {
+			if err = l.Wait(timeout, "api", limit); err != nil {
+				break
+			}
+		}
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("%s: expected deadline exceeded, got %v", name, err)
+		}
+	}
+}
+
+// TestNewLimit ...
+func TestNewLimit(t *testing.T) {
+	if limit, err := NewLimit(5, time.Second, 10); err != nil || limit.burst() != 10 {
+		t.Fatalf("new limit fail, limit: %+v err: %v", limit, err)
+	}
+	for _, rate := range []int64{0, -1} {
+		if _, err := NewLimit(rate, time.Second, 0); !errors.Is(err, ErrInvalidLimit) {
+			t.Fatalf("expected ErrInvalidLimit for rate %d, got %v", rate, err)
+		}
+	}
+	for _, period := range []time.Duration{0, -time.Second, time.Microsecond} {
+		if _, err := NewLimit(5, period, 0); !errors.Is(err, ErrInvalidLimit) {
+			t.Fatalf("expected ErrInvalidLimit for period %v, got %v", period, err)
+		}
+	}
+}
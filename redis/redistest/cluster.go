@@ -0,0 +1,103 @@
+package redistest
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"strings"
+)
+
+// ClusterClient 以集群模式连接替身的客户端，测试结束时自动关闭
+// 替身只有一个节点且不校验 slot，客户端拒绝 KEYS 不在同一 slot 的脚本，与真实集群一样返回 CROSSSLOT 错误
+func (s *Server) ClusterClient() *redis.ClusterClient {
+	rdb := redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs:    []string{s.Addr()},
+		Password: s.password,
+	})
+	rdb.AddHook(crossSlotHook{})
+	s.tb.Cleanup(func() {
+		rdb.Close()
+	})
+	return rdb
+}
+
+// crossSlotHook ...
+type crossSlotHook struct{}
+
+// DialHook ...
+func (crossSlotHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook ...
+func (crossSlotHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := checkSlots(cmd); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+// ProcessPipelineHook ...
+func (crossSlotHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			if err := checkSlots(cmd); err != nil {
+				cmd.SetErr(err)
+				return err
+			}
+		}
+		return next(ctx, cmds)
+	}
+}
+
+// checkSlots 校验 EVAL/EVALSHA 的 KEYS 在同一 slot
+func checkSlots(cmd redis.Cmder) error {
+	switch strings.ToLower(cmd.Name()) {
+	case "eval", "evalsha", "eval_ro", "evalsha_ro":
+	default:
+		return nil
+	}
+
+	args := cmd.Args()
+	if len(args) < 3 {
+		return nil
+	}
+	numKeys, err := strconv.Atoi(fmt.Sprint(args[2]))
+	if err != nil || numKeys < 2 || len(args) < 3+numKeys {
+		return nil
+	}
+	slot := Slot(fmt.Sprint(args[3]))
+	for _, key := range args[4 : 3+numKeys] {
+		if Slot(fmt.Sprint(key)) != slot {
+			return fmt.Errorf("CROSSSLOT Keys in request don't hash to the same slot: %v", args[3:3+numKeys])
+		}
+	}
+	return nil
+}
+
+// Slot key 所在的集群 slot，key 含 hash tag({...})时只计算 tag 部分
+func Slot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+
+	// CRC16-CCITT(XMODEM)
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return int(crc) % 16384
+}
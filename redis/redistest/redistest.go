@@ -0,0 +1,123 @@
+// Package redistest 为 redisson 及其使用方提供内嵌的redis协议替身，测试无需外部redis
+// 基于 miniredis，支持 EVAL/EVALSHA、哈希、PEXPIRE/PTTL、PUBLISH/SUBSCRIBE、Streams 等命令
+package redistest
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TickInterval 实时模式下推进替身时钟的间隔
+const TickInterval = 10 * time.Millisecond
+
+// Option 替身配置
+type Option func(s *Server)
+
+// WithPassword 要求客户端使用密码认证
+func WithPassword(password string) Option {
+	return func(s *Server) {
+		s.password = password
+	}
+}
+
+// WithFrozenClock 冻结替身时钟，key 只在调用 Advance 时过期
+func WithFrozenClock() Option {
+	return func(s *Server) {
+		s.frozen = true
+	}
+}
+
+// Server 内嵌的redis替身
+// 默认时钟随真实时间推进，key 按真实时间过期；冻结时钟后通过 Advance 精确控制过期
+type Server struct {
+	*miniredis.Miniredis
+
+	tb       testing.TB
+	password string
+	frozen   bool
+
+	mutex sync.Mutex
+	now   time.Time
+	stop  chan struct{}
+}
+
+// Run 启动替身，测试结束时自动关闭
+func Run(tb testing.TB, opts ...Option) *Server {
+	tb.Helper()
+
+	s := &Server{
+		Miniredis: miniredis.NewMiniRedis(),
+		tb:        tb,
+		now:       time.Now(),
+		stop:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.password != "" {
+		s.RequireAuth(s.password)
+	}
+	s.SetTime(s.now)
+
+	if err := s.Start(); err != nil {
+		tb.Fatalf("start redis stand-in fail, err: %v", err)
+	}
+	if !s.frozen {
+		go s.tick()
+	}
+
+	tb.Cleanup(func() {
+		close(s.stop)
+		s.Close()
+	})
+	return s
+}
+
+// tick 按真实流逝时间推进替身时钟
+func (s *Server) tick() {
+	ticker := time.NewTicker(TickInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.Advance(now.Sub(last))
+			last = now
+		}
+	}
+}
+
+// Advance 推进替身时钟，到期的 key 被删除，TIME 命令返回推进后的时间
+func (s *Server) Advance(d time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.now = s.now.Add(d)
+	s.SetTime(s.now)
+	s.FastForward(d)
+}
+
+// Now 替身时钟的当前时间
+func (s *Server) Now() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.now
+}
+
+// Client 新建连接替身的客户端，测试结束时自动关闭；多次调用可模拟多个实例
+func (s *Server) Client() *redis.Client {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     s.Addr(),
+		Password: s.password,
+	})
+	s.tb.Cleanup(func() {
+		rdb.Close()
+	})
+	return rdb
+}
@@ -0,0 +1,95 @@
+package redistest
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAdvance ...
+func TestAdvance(t *testing.T) {
+	s := Run(t, WithPassword("demo"), WithFrozenClock())
+	rdb := s.Client()
+	ctx := context.Background()
+
+	start := s.Now()
+	rdb.HSet(ctx, "lock", "owner", 1)
+	rdb.PExpire(ctx, "lock", time.Second)
+
+	// 时钟冻结，真实时间流逝不影响过期
+	time.Sleep(50 * time.Millisecond)
+	if ttl := rdb.PTTL(ctx, "lock").Val(); ttl != time.Second {
+		t.Fatalf("expected ttl 1s, got %v", ttl)
+	}
+
+	s.Advance(600 * time.Millisecond)
+	if ttl := rdb.PTTL(ctx, "lock").Val(); ttl != 400*time.Millisecond {
+		t.Fatalf("expected ttl 400ms, got %v", ttl)
+	}
+	if now, _ := rdb.Time(ctx).Result(); !now.Equal(start.Add(600 * time.Millisecond).Truncate(time.Microsecond)) {
+		t.Fatalf("unexpected server time %v", now)
+	}
+
+	s.Advance(400 * time.Millisecond)
+	if rdb.Exists(ctx, "lock").Val() != 0 {
+		t.Fatalf("expected key expired")
+	}
+}
+
+// TestRealClock ...
+func TestRealClock(t *testing.T) {
+	s := Run(t)
+	rdb := s.Client()
+	ctx := context.Background()
+
+	rdb.Set(ctx, "key", "value", 50*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	if rdb.Exists(ctx, "key").Val() != 0 {
+		t.Fatalf("expected key expired in real time")
+	}
+
+	// 脚本及发布订阅
+	script := redis.NewScript(`return redis.call('hincrby', KEYS[1], ARGV[1], 1)`)
+	for i := int64(1); i <= 2; i++ {
+		if n, err := script.Run(ctx, rdb, []string{"hash"}, "field").Int64(); err != nil || n != i {
+			t.Fatalf("expected %d, got %d err: %v", i, n, err)
+		}
+	}
+
+	pubsub := s.Client().Subscribe(ctx, "channel")
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		t.Fatalf("subscribe fail, err: %v", err)
+	}
+	rdb.Publish(ctx, "channel", "message")
+	select {
+	case msg := <-pubsub.Channel():
+		if msg.Payload != "message" {
+			t.Fatalf("unexpected message %v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected message")
+	}
+}
+
+// TestClusterClient ...
+func TestClusterClient(t *testing.T) {
+	if slot := Slot("123456789"); slot != 12739 {
+		t.Fatalf("expected slot 12739, got %d", slot)
+	}
+	if Slot("{user1000}.following") != Slot("{user1000}.followers") || Slot("{}a") == Slot("{}b") {
+		t.Fatalf("unexpected hash tag slots")
+	}
+
+	rdb := Run(t).ClusterClient()
+	ctx := context.Background()
+	script := redis.NewScript(`return redis.call('SET', KEYS[1], KEYS[2])`)
+	if err := script.Run(ctx, rdb, []string{"{a}1", "{a}2"}).Err(); err != nil {
+		t.Fatalf("same slot script fail, err: %v", err)
+	}
+	if err := script.Run(ctx, rdb, []string{"a1", "a2"}).Err(); err == nil || !strings.HasPrefix(err.Error(), "CROSSSLOT") {
+		t.Fatalf("expected CROSSSLOT, got %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// RedLockClockDriftFactor 时钟漂移系数，有效期需扣除 过期时间*系数+2ms
+	RedLockClockDriftFactor = 0.01
+	// RedLockRetryDelay 多节点加锁失败后的最大随机重试间隔
+	RedLockRetryDelay = 200 * time.Millisecond
+	// RedLockNodeTimeoutFactor 单个节点加锁超时系数，超时时间为 过期时间*系数，避免个别节点无响应耗尽有效期
+	RedLockNodeTimeoutFactor = 0.05
+)
+
+// ErrNoQuorum 未能在多数节点加锁成功，或扣除耗时、时钟漂移后有效期已耗尽
+var ErrNoQuorum = errors.New("lock quorum not reached")
+
+// RRedLock 多节点锁(Redlock)，在多数独立节点加锁成功且有效期未耗尽时视为加锁成功
+type RRedLock struct {
+	nodes   []*RLock
+	owner   string
+	metrics *LockMetrics
+}
+
+// NewRRedLock 节点需为相互独立的redis实例，至少一个节点连接成功
+func NewRRedLock(ctx context.Context, rdbs ...redis.Cmdable) *RRedLock {
+	nodes := make([]*RLock, 0, len(rdbs))
+	for _, rdb := range rdbs {
+		// 连接失败的节点同样参与多数派计算，加锁时视为失败
+		nodes = append(nodes, newRLock(rdb))
+	}
+
+	for _, rdb := range rdbs {
+		if _, err := rdb.Ping(ctx).Result(); err == nil {
+			return &RRedLock{nodes: nodes}
+		}
+	}
+	return nil
+}
+
+// WithOwner 返回绑定持有者标识的RRedLock副本，使用相同持有者标识加锁即为锁重入
+func (redLock *RRedLock) WithOwner(owner string) *RRedLock {
+	if redLock == nil {
+		return nil
+	}
+
+	return &RRedLock{
+		nodes:   redLock.nodes,
+		owner:   owner,
+		metrics: redLock.metrics,
+	}
+}
+
+// WithMetrics 返回记录prometheus指标的RRedLock副本
+func (redLock *RRedLock) WithMetrics(metrics *LockMetrics) *RRedLock {
+	if redLock == nil {
+		return nil
+	}
+
+	return &RRedLock{
+		nodes:   redLock.nodes,
+		owner:   redLock.owner,
+		metrics: metrics,
+	}
+}
+
+// quorum 多数派节点数
+func (redLock *RRedLock) quorum() int {
+	return len(redLock.nodes)/2 + 1
+}
+
+// token ...
+func (redLock *RRedLock) token() string {
+	if redLock.owner != "" {
+		return redLock.owner
+	}
+	return NewOwnerToken()
+}
+
+// each 并发在 nodes 上执行 fn，返回成功的节点
+func each(nodes []*RLock, fn func(node *RLock) bool) []*RLock {
+	var (
+		wg        sync.WaitGroup
+		mutex     sync.Mutex
+		succeeded []*RLock
+	)
+
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node *RLock) {
+			defer wg.Done()
+			if fn(node) {
+				mutex.Lock()
+				succeeded = append(succeeded, node)
+				mutex.Unlock()
+			}
+		}(node)
+	}
+
+	wg.Wait()
+	return succeeded
+}
+
+// acquire 在所有节点加锁，多数派成功且扣除耗时、时钟漂移后仍有有效期则返回锁句柄
+func (redLock *RRedLock) acquire(ctx context.Context, name, token string, expiration time.Duration) (*LockHandle, error) {
+	start := time.Now()
+	lease := expiration * time.Millisecond
+	nodeTimeout := time.Duration(float64(lease) * RedLockNodeTimeoutFactor)
+	locked := each(redLock.nodes, func(node *RLock) bool {
+		ctx, cancel := context.WithTimeout(ctx, nodeTimeout)
+		defer cancel()
+		ret, err := rLockScript.Run(ctx, node.rdb, []string{name}, int64(expiration), token).Result()
+		return err == nil && ret.(int64) <= 0
+	})
+	count := len(locked)
+
+	drift := time.Duration(float64(lease)*RedLockClockDriftFactor) + 2*time.Millisecond
+	validity := lease - time.Since(start) - drift
+
+	if count >= redLock.quorum() && validity > 0 {
+		h := &LockHandle{
+			rLock:      redLock.nodes[0],
+			name:       name,
+			token:      token,
+			expiration: lease,
+			validUntil: start.Add(lease - drift),
+			acquiredAt: start,
+			metrics:    redLock.metrics,
+			release:    redLock.release,
+			renewal:    redLock.renew,
+		}
+		return h, nil
+	}
+
+	// 未达成多数派或有效期已耗尽：只释放本次加锁成功的节点
+	// 失败节点上可能有同一 owner 之前重入持有的锁，不能扣减其计数
+	releaseNodes(context.WithoutCancel(ctx), locked, &LockHandle{name: name, token: token, expiration: lease})
+	return nil, fmt.Errorf("%w: %d/%d", ErrNoQuorum, count, len(redLock.nodes))
+}
+
+// Lock 尝试加锁一次，未达成多数派时返回 ErrNoQuorum
+func (redLock *RRedLock) Lock(ctx context.Context, name string, args ...time.Duration) (h *LockHandle, err error) {
+	if redLock == nil {
+		return nil, ErrNotConnected
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	defer func() { redLock.metrics.observeAcquire(name, start, err) }()
+
+	expiration, err := redLock.nodes[0].buildLockArgs(args...)
+	if err != nil {
+		return nil, err
+	}
+	return redLock.acquire(ctx, name, redLock.token(), expiration)
+}
+
+// TryLock 加锁失败后随机退避重试，直到超时返回 ErrTimeout 或 ctx 取消
+func (redLock *RRedLock) TryLock(ctx context.Context, name string, args ...time.Duration) (h *LockHandle, err error) {
+	if redLock == nil {
+		return nil, ErrNotConnected
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	defer func() { redLock.metrics.observeAcquire(name, start, err) }()
+
+	expiration, timeout, err := redLock.nodes[0].buildTryLockArgs(args...)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+
+	token := redLock.token()
+	for {
+		h, err := redLock.acquire(ctx, name, token, expiration)
+		if err == nil {
+			return h, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, ErrTimeout
+		}
+		wait := time.Duration(rand.Int63n(int64(RedLockRetryDelay))) + time.Millisecond
+		if wait > remaining {
+			wait = remaining
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// release 在所有节点解锁，返回值含义同 rUnlockMsg，取各节点结果中的最大值
+func (redLock *RRedLock) release(ctx context.Context, h *LockHandle) (interface{}, error) {
+	return releaseNodes(ctx, redLock.nodes, h)
+}
+
+// releaseNodes 在 nodes 上解锁
+func releaseNodes(ctx context.Context, nodes []*RLock, h *LockHandle) (interface{}, error) {
+	var (
+		mutex   sync.Mutex
+		code    int64
+		lastErr error
+	)
+
+	released := each(nodes, func(node *RLock) bool {
+		ret, err := releaseLock(ctx, &LockHandle{rLock: node, name: h.name, token: h.token, expiration: h.expiration})
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err != nil {
+			lastErr = err
+			return false
+		}
+		if ret.(int64) > code {
+			code = ret.(int64)
+		}
+		return true
+	})
+
+	if len(released) == 0 {
+		return nil, lastErr
+	}
+	return code, nil
+}
+
+// renew 在所有节点续期，多数派成功时视为续期成功
+func (redLock *RRedLock) renew(ctx context.Context, h *LockHandle) (interface{}, error) {
+	renewed := each(redLock.nodes, func(node *RLock) bool {
+		ret, err := renewLock(ctx, &LockHandle{rLock: node, name: h.name, token: h.token, expiration: h.expiration})
+		return err == nil && ret.(int64) == 1
+	})
+
+	if len(renewed) >= redLock.quorum() {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
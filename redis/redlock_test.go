@@ -0,0 +1,223 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+// newRedLockNodes 启动 n 个独立的进程内redis
+func newRedLockNodes(t *testing.T, n int) ([]*redistest.Server, []redis.Cmdable) {
+	servers := make([]*redistest.Server, 0, n)
+	rdbs := make([]redis.Cmdable, 0, n)
+	for i := 0; i < n; i++ {
+		s := redistest.Run(t)
+		servers = append(servers, s)
+		rdbs = append(rdbs, redis.NewClient(&redis.Options{
+			Addr:        s.Addr(),
+			MaxRetries:  0,
+			DialTimeout: 100 * time.Millisecond,
+		}))
+	}
+	return servers, rdbs
+}
+
+// TestRedLock ...
+func TestRedLock(t *testing.T) {
+	servers, rdbs := newRedLockNodes(t, 5)
+	ctx := context.Background()
+	redLock := NewRRedLock(ctx, rdbs...)
+
+	l, err := redLock.Lock(ctx, "myRedLock")
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+	if !l.ValidUntil().After(time.Now()) {
+		t.Fatalf("expected validity window")
+	}
+	for _, s := range servers {
+		if !s.Exists("myRedLock") {
+			t.Fatalf("expected lock on every node")
+		}
+	}
+
+	if _, err = redLock.Lock(ctx, "myRedLock"); err == nil {
+		t.Fatalf("expected lock fail while held")
+	}
+
+	if err = l.Unlock(ctx); err != nil {
+		t.Fatalf("unlock fail, err: %v", err)
+	}
+	for _, s := range servers {
+		if s.Exists("myRedLock") {
+			t.Fatalf("expected lock released on every node")
+		}
+	}
+}
+
+// TestRedLockQuorum ...
+func TestRedLockQuorum(t *testing.T) {
+	servers, rdbs := newRedLockNodes(t, 5)
+	ctx := context.Background()
+	redLock := NewRRedLock(ctx, rdbs...)
+
+	// 两个节点宕机仍可达成多数派
+	servers[0].Close()
+	servers[1].Close()
+	l, err := redLock.Lock(ctx, "myQuorumLock")
+	if err != nil {
+		t.Fatalf("lock fail with 3/5 nodes, err: %v", err)
+	}
+	l.Unlock(ctx)
+
+	// 三个节点宕机无法达成多数派，且不残留锁
+	servers[2].Close()
+	if _, err = redLock.Lock(ctx, "myQuorumLock"); err == nil {
+		t.Fatalf("expected lock fail with 2/5 nodes")
+	}
+	for _, s := range servers[3:] {
+		if s.Exists("myQuorumLock") {
+			t.Fatalf("expected partial locks released")
+		}
+	}
+}
+
+// TestRedLockContention ...
+func TestRedLockContention(t *testing.T) {
+	servers, rdbs := newRedLockNodes(t, 3)
+	ctx := context.Background()
+	redLock := NewRRedLock(ctx, rdbs...)
+
+	// 其他持有者占用两个节点
+	servers[0].HSet("myContendedLock", "other", "1")
+	servers[1].HSet("myContendedLock", "other", "1")
+	servers[0].SetTTL("myContendedLock", LockExpiration)
+	servers[1].SetTTL("myContendedLock", LockExpiration)
+	if _, err := redLock.Lock(ctx, "myContendedLock"); err == nil {
+		t.Fatalf("expected lock fail without quorum")
+	}
+	if servers[2].Exists("myContendedLock") {
+		t.Fatalf("expected minority lock released")
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		servers[0].Del("myContendedLock")
+	}()
+	l, err := redLock.TryLock(ctx, "myContendedLock", LockExpiration, 2*time.Second)
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+	l.Unlock(ctx)
+}
+
+// failLockHook 使加锁脚本返回错误，模拟节点超时
+type failLockHook struct{}
+
+// DialHook ...
+func (failLockHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook ...
+func (failLockHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if args := cmd.Args(); len(args) > 1 && (args[1] == rLockScript.Hash()) {
+			cmd.SetErr(errors.New("i/o timeout"))
+			return cmd.Err()
+		}
+		return next(ctx, cmd)
+	}
+}
+
+// ProcessPipelineHook ...
+func (failLockHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// TestRedLockReentrantRelease ...
+func TestRedLockReentrantRelease(t *testing.T) {
+	servers, rdbs := newRedLockNodes(t, 3)
+	ctx := context.Background()
+	redLock := NewRRedLock(ctx, rdbs...).WithOwner("owner")
+
+	h, err := redLock.Lock(ctx, "myReentrantLock")
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+
+	// 重入加锁时两个节点失败，只释放本次加锁成功的节点
+	rdbs[0].(*redis.Client).AddHook(failLockHook{})
+	rdbs[1].(*redis.Client).AddHook(failLockHook{})
+	if _, err = redLock.Lock(ctx, "myReentrantLock"); !errors.Is(err, ErrNoQuorum) {
+		t.Fatalf("expected ErrNoQuorum, got %v", err)
+	}
+	for i, s := range servers {
+		if count := s.HGet("myReentrantLock", "owner"); count != "1" {
+			t.Fatalf("node %d: expected earlier hold kept, got %q", i, count)
+		}
+	}
+	if err = h.Unlock(ctx); err != nil {
+		t.Fatalf("unlock fail, err: %v", err)
+	}
+}
+
+// hangLockHook 使加锁脚本无响应直到 ctx 取消，模拟节点挂起
+type hangLockHook struct{}
+
+// DialHook ...
+func (hangLockHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook ...
+func (hangLockHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if args := cmd.Args(); len(args) > 1 && (args[1] == rLockScript.Hash()) {
+			<-ctx.Done()
+			cmd.SetErr(ctx.Err())
+			return cmd.Err()
+		}
+		return next(ctx, cmd)
+	}
+}
+
+// ProcessPipelineHook ...
+func (hangLockHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// TestRedLockNodeTimeout ...
+func TestRedLockNodeTimeout(t *testing.T) {
+	_, rdbs := newRedLockNodes(t, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	redLock := NewRRedLock(ctx, rdbs...)
+
+	// 挂起的节点不耗尽有效期
+	rdbs[0].(*redis.Client).AddHook(hangLockHook{})
+	start := time.Now()
+	h, err := redLock.Lock(ctx, "myHangLock", time.Second)
+	if err != nil {
+		t.Fatalf("expected quorum without hung node, err: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected hung node timed out, waited %v", elapsed)
+	}
+	h.Unlock(ctx)
+
+	// 已取消的 ctx 不发起请求
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	metrics := NewLockMetrics("test", nil)
+	if _, err = redLock.WithMetrics(metrics).TryLock(canceled, "myHangLock"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+	if n := testutil.CollectAndCount(metrics, "test_lock_wait_seconds"); n != 0 {
+		t.Fatalf("expected no wait observed, got %d", n)
+	}
+}
@@ -0,0 +1,345 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	// RWLockTimeoutSuffix 读写锁读者过期时间后缀，key 为 {name}:rwlock_timeout
+	RWLockTimeoutSuffix = ":rwlock_timeout"
+	// RWLockWriteSuffix 读写锁写锁字段后缀
+	RWLockWriteSuffix = ":write"
+)
+
+// ErrLockUpgrade 持有读锁时申请写锁
+var ErrLockUpgrade = errors.New("read lock can not be upgraded to write lock")
+
+// rwPurgeScript 移除已过期的读者，读模式下已无读者时删除锁
+const rwPurgeScript = `
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[3]);
+for i = 1, #expired, 1 do
+    redis.call('HDEL', KEYS[1], expired[i]);
+end;
+if #expired > 0 then
+    redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', ARGV[3]);
+end;
+local mode = redis.call('HGET', KEYS[1], 'mode');
+if mode == 'read' and redis.call('HLEN', KEYS[1]) == 1 then
+    redis.call('DEL', KEYS[1], KEYS[2]);
+    mode = false;
+end;
+`
+
+// rReadLockScript ...
+var rReadLockScript = redis.NewScript(rwPurgeScript + `
+-- 若锁不存在：则以读模式加锁
+if (mode == false) then
+    redis.call('HSET', KEYS[1], 'mode', 'read');
+    redis.call('HSET', KEYS[1], ARGV[2], 1);
+    redis.call('PEXPIRE', KEYS[1], ARGV[1]);
+    redis.call('ZADD', KEYS[2], tonumber(ARGV[3]) + tonumber(ARGV[1]), ARGV[2]);
+    redis.call('PEXPIRE', KEYS[2], ARGV[1]);
+    return -1;
+end;
+
+-- 读模式，或写锁持有者申请读锁(锁降级)：读者计数+1，并刷新读者过期时间
+if (mode == 'read') or (redis.call('HEXISTS', KEYS[1], ARGV[4]) == 1) then
+    local counter = redis.call('HINCRBY', KEYS[1], ARGV[2], 1);
+    redis.call('ZADD', KEYS[2], tonumber(ARGV[3]) + tonumber(ARGV[1]), ARGV[2]);
+    if (redis.call('PTTL', KEYS[1]) < tonumber(ARGV[1])) then
+        redis.call('PEXPIRE', KEYS[1], ARGV[1]);
+        redis.call('PEXPIRE', KEYS[2], ARGV[1]);
+    end;
+    if (counter == 1) then
+        return -1;
+    end;
+    return -2;
+end;
+
+-- 写锁被其他线程持有：返回锁剩余过期时间
+return redis.call('PTTL', KEYS[1]);
+`)
+
+// rWriteLockScript ...
+var rWriteLockScript = redis.NewScript(rwPurgeScript + `
+-- 若锁不存在：则以写模式加锁
+if (mode == false) then
+    redis.call('HSET', KEYS[1], 'mode', 'write');
+    redis.call('HSET', KEYS[1], ARGV[4], 1);
+    redis.call('PEXPIRE', KEYS[1], ARGV[1]);
+    return -1;
+end;
+
+-- 写模式且为当前线程持有：写锁重入
+if (mode == 'write') then
+    if (redis.call('HEXISTS', KEYS[1], ARGV[4]) == 1) then
+        redis.call('HINCRBY', KEYS[1], ARGV[4], 1);
+        redis.call('PEXPIRE', KEYS[1], ARGV[1]);
+        return -2;
+    end;
+    return redis.call('PTTL', KEYS[1]);
+end;
+
+-- 读模式且当前线程持有读锁：不允许升级，直接返回避免死锁
+if (redis.call('HEXISTS', KEYS[1], ARGV[2]) == 1) then
+    return -3;
+end;
+
+-- 读锁被其他线程持有：返回锁剩余过期时间
+return redis.call('PTTL', KEYS[1]);
+`)
+
+// rReadUnlockScript ...
+var rReadUnlockScript = redis.NewScript(`
+local mode = redis.call('HGET', KEYS[1], 'mode');
+if (mode == false) then
+    redis.call('publish', KEYS[3], ARGV[1]);
+    return 1;
+end;
+
+if (redis.call('HEXISTS', KEYS[1], ARGV[3]) == 0) then
+    return 2;
+end;
+
+local counter = redis.call('HINCRBY', KEYS[1], ARGV[3], -1);
+if (counter > 0) then
+    return 3;
+end;
+
+redis.call('HDEL', KEYS[1], ARGV[3]);
+redis.call('ZREM', KEYS[2], ARGV[3]);
+
+-- 读模式下最后一个读者释放：删除锁，并广播解锁消息唤醒写者
+if (mode == 'read') and (redis.call('HLEN', KEYS[1]) == 1) then
+    redis.call('DEL', KEYS[1], KEYS[2]);
+    redis.call('publish', KEYS[3], ARGV[1]);
+end;
+return 4;
+`)
+
+// rWriteUnlockScript ...
+var rWriteUnlockScript = redis.NewScript(`
+local mode = redis.call('HGET', KEYS[1], 'mode');
+if (mode == false) then
+    redis.call('publish', KEYS[3], ARGV[1]);
+    return 1;
+end;
+
+if (mode ~= 'write') or (redis.call('HEXISTS', KEYS[1], ARGV[3]) == 0) then
+    return 2;
+end;
+
+local counter = redis.call('HINCRBY', KEYS[1], ARGV[3], -1);
+if (counter > 0) then
+    redis.call('PEXPIRE', KEYS[1], ARGV[2]);
+    return 3;
+end;
+
+redis.call('HDEL', KEYS[1], ARGV[3]);
+if (redis.call('HLEN', KEYS[1]) == 1) then
+    redis.call('DEL', KEYS[1], KEYS[2]);
+else
+    -- 写锁持有者仍持有读锁：降级为读模式，唤醒等待的读者
+    redis.call('HSET', KEYS[1], 'mode', 'read');
+end;
+redis.call('publish', KEYS[3], ARGV[1]);
+return 4;
+`)
+
+// rReadRenewScript ...
+var rReadRenewScript = redis.NewScript(`
+if (redis.call('HEXISTS', KEYS[1], ARGV[2]) == 1) then
+    redis.call('ZADD', KEYS[2], tonumber(ARGV[3]) + tonumber(ARGV[1]), ARGV[2]);
+    if (redis.call('PTTL', KEYS[1]) < tonumber(ARGV[1])) then
+        redis.call('PEXPIRE', KEYS[1], ARGV[1]);
+        redis.call('PEXPIRE', KEYS[2], ARGV[1]);
+    end;
+    return 1;
+end;
+return 0;
+`)
+
+// RReadWriteLock 读写锁
+// 读锁之间共享，写锁独占；读锁、写锁均可按持有者标识重入
+// 锁降级：持有写锁的持有者可以再申请读锁，释放写锁后仍持有读锁
+// 锁升级：持有读锁的持有者申请写锁直接返回 ErrLockUpgrade，需先释放读锁
+type RReadWriteLock struct {
+	rLock *RLock
+	name  string
+}
+
+// RWLocker 读写锁的读锁或写锁
+type RWLocker struct {
+	rw    *RReadWriteLock
+	write bool
+}
+
+// NewRReadWriteLock ...
+func NewRReadWriteLock(ctx context.Context, rdb redis.Cmdable, name string) *RReadWriteLock {
+	rLock := NewRLock(ctx, rdb)
+	if rLock == nil {
+		return nil
+	}
+
+	return &RReadWriteLock{
+		rLock: rLock,
+		name:  name,
+	}
+}
+
+// WithOwner 返回绑定持有者标识的RReadWriteLock副本，锁重入、锁降级需使用相同持有者标识
+func (rw *RReadWriteLock) WithOwner(owner string) *RReadWriteLock {
+	if rw == nil {
+		return nil
+	}
+
+	return &RReadWriteLock{
+		rLock: rw.rLock.WithOwner(owner),
+		name:  rw.name,
+	}
+}
+
+// WithMetrics 返回记录prometheus指标的RReadWriteLock副本
+func (rw *RReadWriteLock) WithMetrics(metrics *LockMetrics) *RReadWriteLock {
+	if rw == nil {
+		return nil
+	}
+
+	return &RReadWriteLock{
+		rLock: rw.rLock.WithMetrics(metrics),
+		name:  rw.name,
+	}
+}
+
+// ReadLock 读锁
+func (rw *RReadWriteLock) ReadLock() *RWLocker {
+	return &RWLocker{rw: rw}
+}
+
+// WriteLock 写锁
+func (rw *RReadWriteLock) WriteLock() *RWLocker {
+	return &RWLocker{rw: rw, write: true}
+}
+
+// rwKeys 锁、读者过期时间、锁释放广播频道，读者过期时间与锁在同一 slot
+func rwKeys(name string) []string {
+	return []string{
+		name,
+		hashTag(name) + RWLockTimeoutSuffix,
+		releaseChannel(name),
+	}
+}
+
+// acquire ...
+func (l *RWLocker) acquire(ctx context.Context, token string, expiration time.Duration) (int64, error) {
+	script := rReadLockScript
+	if l.write {
+		script = rWriteLockScript
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	ret, err := script.Run(ctx, l.rw.rLock.rdb, rwKeys(l.rw.name)[:2], int64(expiration), token, now, token+RWLockWriteSuffix).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	l.rw.rLock.printLog(l.rw.name, token, ret)
+	if ret.(int64) == -3 {
+		return 0, ErrLockUpgrade
+	}
+	return ret.(int64), nil
+}
+
+// newHandle ...
+func (l *RWLocker) newHandle(token string, expiration time.Duration) *LockHandle {
+	h := l.rw.rLock.newHandle(l.rw.name, token, expiration)
+	if l.write {
+		h.release = releaseWriteLock
+		h.renewal = renewWriteLock
+	} else {
+		h.release = releaseReadLock
+		h.renewal = renewReadLock
+	}
+	return h
+}
+
+// Lock 尝试加锁一次，锁被占用时返回 *LockHeldError
+func (l *RWLocker) Lock(ctx context.Context, args ...time.Duration) (h *LockHandle, err error) {
+	if l.rw == nil {
+		return nil, ErrNotConnected
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	defer func() { l.rw.rLock.metrics.observeAcquire(l.rw.name, start, err) }()
+
+	expiration, err := l.rw.rLock.buildLockArgs(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	token := l.rw.rLock.token()
+	ret, err := l.acquire(ctx, token, expiration)
+	if err != nil {
+		return nil, err
+	}
+	if ret > 0 {
+		return nil, lockHeld(l.rw.name, ret)
+	}
+
+	return l.newHandle(token, expiration), nil
+}
+
+// TryLock 锁被占用时等待释放后重试，直到超时返回 ErrTimeout 或 ctx 取消
+func (l *RWLocker) TryLock(ctx context.Context, args ...time.Duration) (h *LockHandle, err error) {
+	if l.rw == nil {
+		return nil, ErrNotConnected
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	defer func() { l.rw.rLock.metrics.observeAcquire(l.rw.name, start, err) }()
+
+	expiration, timeout, err := l.rw.rLock.buildTryLockArgs(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	token := l.rw.rLock.token()
+	acquire := func() (int64, error) {
+		return l.acquire(ctx, token, expiration)
+	}
+	if err = waitAcquire(ctx, l.rw.rLock.rdb, releaseChannel(l.rw.name), timeout, 0, acquire); err != nil {
+		return nil, err
+	}
+
+	return l.newHandle(token, expiration), nil
+}
+
+// releaseReadLock ...
+func releaseReadLock(ctx context.Context, h *LockHandle) (interface{}, error) {
+	expiration := int64(h.expiration / time.Millisecond)
+	return rReadUnlockScript.Run(ctx, h.rLock.rdb, rwKeys(h.name), LockReleaseFlag, expiration, h.token).Result()
+}
+
+// releaseWriteLock ...
+func releaseWriteLock(ctx context.Context, h *LockHandle) (interface{}, error) {
+	expiration := int64(h.expiration / time.Millisecond)
+	return rWriteUnlockScript.Run(ctx, h.rLock.rdb, rwKeys(h.name), LockReleaseFlag, expiration, h.token+RWLockWriteSuffix).Result()
+}
+
+// renewReadLock ...
+func renewReadLock(ctx context.Context, h *LockHandle) (interface{}, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	return rReadRenewScript.Run(ctx, h.rLock.rdb, rwKeys(h.name)[:2], int64(h.expiration/time.Millisecond), h.token, now).Result()
+}
+
+// renewWriteLock ...
+func renewWriteLock(ctx context.Context, h *LockHandle) (interface{}, error) {
+	return rRenewScript.Run(ctx, h.rLock.rdb, []string{h.name}, int64(h.expiration/time.Millisecond), h.token+RWLockWriteSuffix).Result()
+}
@@ -0,0 +1,148 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"testing"
+	"time"
+)
+
+// TestReadWriteLock ...
+func TestReadWriteLock(t *testing.T) {
+	rdb := redistest.Run(t).Client()
+	ctx := context.Background()
+	rw := NewRReadWriteLock(ctx, rdb, "myRWLock")
+
+	// 读锁共享
+	r1, err := rw.ReadLock().Lock(ctx)
+	if err != nil {
+		t.Fatalf("read lock fail, err: %v", err)
+	}
+	r2, err := rw.ReadLock().Lock(ctx)
+	if err != nil {
+		t.Fatalf("read lock fail, err: %v", err)
+	}
+
+	// 存在读者时写锁互斥
+	if _, err = rw.WriteLock().Lock(ctx); err == nil {
+		t.Fatalf("expected write lock fail while readers hold the lock")
+	}
+
+	// 写者等待所有读者释放
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		r1.Unlock(ctx)
+		time.Sleep(100 * time.Millisecond)
+		r2.Unlock(ctx)
+	}()
+	w, err := rw.WriteLock().TryLock(ctx, LockExpiration, 3*time.Second)
+	if err != nil {
+		t.Fatalf("write lock fail, err: %v", err)
+	}
+
+	// 写锁持有期间读锁互斥
+	if _, err = rw.ReadLock().Lock(ctx); err == nil {
+		t.Fatalf("expected read lock fail while writer holds the lock")
+	}
+	if err = w.Unlock(ctx); err != nil {
+		t.Fatalf("unlock fail, err: %v", err)
+	}
+	if rdb.Exists(ctx, "myRWLock").Val() != 0 {
+		t.Fatalf("expected lock released")
+	}
+
+	// 已取消的 ctx 直接返回，不计入等待指标
+	metrics := NewLockMetrics("test", nil)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	for _, l := range []*RWLocker{rw.WithMetrics(metrics).ReadLock(), rw.WithMetrics(metrics).WriteLock()} {
+		if _, err = l.TryLock(canceled); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context canceled, got %v", err)
+		}
+	}
+	if n := testutil.CollectAndCount(metrics, "test_lock_wait_seconds"); n != 0 {
+		t.Fatalf("expected no wait observed, got %d", n)
+	}
+}
+
+// TestReadWriteLockDowngrade ...
+func TestReadWriteLockDowngrade(t *testing.T) {
+	rdb := redistest.Run(t).Client()
+	ctx := context.Background()
+	rw := NewRReadWriteLock(ctx, rdb, "myRWDowngrade")
+	owner := rw.WithOwner(NewOwnerToken())
+
+	w, err := owner.WriteLock().Lock(ctx)
+	if err != nil {
+		t.Fatalf("write lock fail, err: %v", err)
+	}
+	r, err := owner.ReadLock().Lock(ctx)
+	if err != nil {
+		t.Fatalf("expected downgrade allowed, err: %v", err)
+	}
+	if err = w.Unlock(ctx); err != nil {
+		t.Fatalf("unlock fail, err: %v", err)
+	}
+
+	// 降级后其他读者可加锁，写者互斥
+	other, err := rw.ReadLock().Lock(ctx)
+	if err != nil {
+		t.Fatalf("expected read lock after downgrade, err: %v", err)
+	}
+	if _, err = rw.WriteLock().Lock(ctx); err == nil {
+		t.Fatalf("expected write lock fail after downgrade")
+	}
+	other.Unlock(ctx)
+	r.Unlock(ctx)
+	if rdb.Exists(ctx, "myRWDowngrade").Val() != 0 {
+		t.Fatalf("expected lock released")
+	}
+}
+
+// TestReadWriteLockUpgrade ...
+func TestReadWriteLockUpgrade(t *testing.T) {
+	rdb := redistest.Run(t).Client()
+	ctx := context.Background()
+	owner := NewRReadWriteLock(ctx, rdb, "myRWUpgrade").WithOwner(NewOwnerToken())
+
+	r, err := owner.ReadLock().Lock(ctx)
+	if err != nil {
+		t.Fatalf("read lock fail, err: %v", err)
+	}
+	defer r.Unlock(ctx)
+
+	if _, err = owner.WriteLock().TryLock(ctx, LockExpiration, time.Second); !errors.Is(err, ErrLockUpgrade) {
+		t.Fatalf("expected ErrLockUpgrade, got %v", err)
+	}
+}
+
+// TestReadWriteLockCluster ...
+func TestReadWriteLockCluster(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.ClusterClient()
+	ctx := context.Background()
+
+	for _, name := range []string{"myClusterRWLock", "{order}:rw"} {
+		rw := NewRReadWriteLock(ctx, rdb, name)
+		r, err := rw.ReadLock().Lock(ctx)
+		if err != nil {
+			t.Fatalf("%s: read lock fail, err: %v", name, err)
+		}
+		if err = r.Unlock(ctx); err != nil {
+			t.Fatalf("%s: read unlock fail, err: %v", name, err)
+		}
+		w, err := rw.WriteLock().TryLock(ctx, LockExpiration, time.Second)
+		if err != nil {
+			t.Fatalf("%s: write lock fail, err: %v", name, err)
+		}
+		if err = w.Unlock(ctx); err != nil {
+			t.Fatalf("%s: write unlock fail, err: %v", name, err)
+		}
+		rw.ReadLock().Lock(ctx)
+		if ok, err := NewRLock(ctx, rdb).ForceUnlock(ctx, name); !ok || err != nil {
+			t.Fatalf("%s: force unlock fail, ok: %v err: %v", name, ok, err)
+		}
+	}
+}
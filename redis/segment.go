@@ -0,0 +1,193 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+const (
+	// SegmentStep 每次从redis预留的号段长度
+	SegmentStep = 1000
+	// SegmentPrefetchRatio 当前号段剩余比例低于该值时异步预取下一号段
+	SegmentPrefetchRatio = 0.5
+)
+
+// ErrInvalidCount 批量生成的id数量不能为负数
+var ErrInvalidCount = errors.New("id count must not be negative")
+
+// segment 号段 [cur, end)，date 为号段所属日期
+type segment struct {
+	date string
+	cur  int64
+	end  int64
+}
+
+// remaining 号段剩余可用数量
+func (seg *segment) remaining() int64 {
+	return seg.end - seg.cur
+}
+
+// segmentBuffer 单个 key 的双号段缓冲
+type segmentBuffer struct {
+	mutex   sync.Mutex
+	current segment
+	next    *segment
+	loading chan struct{}
+}
+
+// SegmentAllocator 号段模式的全局唯一id生成，每次 INCRBY 预留一段序列号在本地分配
+// key 布局及id编码与 Rdb.NextId 相同，可与其混用
+type SegmentAllocator struct {
+	rdb     redis.Cmdable
+	step    int64
+	mutex   sync.Mutex
+	buffers map[string]*segmentBuffer
+}
+
+// NewSegmentAllocator step<=0 时使用 SegmentStep
+func NewSegmentAllocator(rdb redis.Cmdable, step int64) *SegmentAllocator {
+	if step <= 0 {
+		step = SegmentStep
+	}
+
+	return &SegmentAllocator{
+		rdb:     rdb,
+		step:    step,
+		buffers: make(map[string]*segmentBuffer),
+	}
+}
+
+// buffer ...
+func (a *SegmentAllocator) buffer(key string) *segmentBuffer {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	b, ok := a.buffers[key]
+	if !ok {
+		b = &segmentBuffer{}
+		a.buffers[key] = b
+	}
+	return b
+}
+
+// load 从redis预留一个号段
+func (a *SegmentAllocator) load(ctx context.Context, key, date string) (segment, error) {
+	//key拼接 ="icr:"+ "传参key:" + 当前日期
+	end, err := a.rdb.IncrBy(ctx, NextidKey+key+date, a.step).Result()
+	if err != nil {
+		return segment{}, err
+	}
+	return segment{date: date, cur: end - a.step + 1, end: end + 1}, nil
+}
+
+// prefetch 异步预取下一号段，调用方需持有 b.mutex；预取结果供后续调用共享，不随本次调用的 ctx 取消
+func (a *SegmentAllocator) prefetch(ctx context.Context, b *segmentBuffer, key, date string) {
+	if b.next != nil || b.loading != nil {
+		return
+	}
+	if float64(b.current.remaining()) >= float64(a.step)*SegmentPrefetchRatio {
+		return
+	}
+
+	done := make(chan struct{})
+	b.loading = done
+	go func() {
+		seg, err := a.load(context.WithoutCancel(ctx), key, date)
+
+		b.mutex.Lock()
+		if err == nil {
+			b.next = &seg
+		}
+		b.loading = nil
+		b.mutex.Unlock()
+		close(done)
+	}()
+}
+
+// NextId 全局唯一id生成
+func (a *SegmentAllocator) NextId(ctx context.Context, key string) (int64, error) {
+	ids, err := a.NextIds(ctx, key, 1)
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// NextIds 批量生成 n 个全局唯一id，n 为0时返回空切片
+func (a *SegmentAllocator) NextIds(ctx context.Context, key string, n int) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, ErrInvalidCount
+	}
+	if n == 0 {
+		return []int64{}, nil
+	}
+
+	b := a.buffer(key)
+	ids := make([]int64, 0, n)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for len(ids) < n {
+		now := time.Now()
+		//日期格式:yyyy:MM:dd
+		date := now.Format(":2006:01:02")
+
+		// 跨天后序列号从新 key 重新开始，丢弃旧号段
+		if b.current.date != date {
+			b.current = segment{}
+		}
+		if b.next != nil && b.next.date != date {
+			b.next = nil
+		}
+
+		if b.current.remaining() == 0 {
+			if b.next != nil {
+				b.current, b.next = *b.next, nil
+				continue
+			}
+
+			// 等待进行中的异步预取
+			if done := b.loading; done != nil {
+				b.mutex.Unlock()
+				select {
+				case <-done:
+				case <-ctx.Done():
+					b.mutex.Lock()
+					return nil, ctx.Err()
+				}
+				b.mutex.Lock()
+				continue
+			}
+
+			seg, err := a.load(ctx, key, date)
+			if err != nil {
+				return nil, err
+			}
+			b.current = seg
+			continue
+		}
+
+		count := b.current.remaining()
+		if rest := int64(n - len(ids)); count > rest {
+			count = rest
+		}
+
+		//返回值=（当前时间戳-自定义时间戳）<<COUNT_BITS | 自增长
+		timestamp := (now.Unix() - BeginTimestamp) << CountBits
+		for i := int64(0); i < count; i++ {
+			ids = append(ids, timestamp|(b.current.cur+i))
+		}
+		b.current.cur += count
+
+		a.prefetch(ctx, b, key, date)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,76 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSegmentAllocator ...
+func TestSegmentAllocator(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx := context.Background()
+
+	a := NewSegmentAllocator(rdb, 100)
+	b := NewSegmentAllocator(rdb, 100)
+
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		ids   = make(map[int64]bool)
+	)
+	for _, alloc := range []*SegmentAllocator{a, b} {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(alloc *SegmentAllocator) {
+				defer wg.Done()
+				for j := 0; j < 500; j++ {
+					id, err := alloc.NextId(ctx, "segment")
+					if err != nil {
+						t.Errorf("next id fail, err: %v", err)
+						return
+					}
+					mutex.Lock()
+					if ids[id&(1<<CountBits-1)] {
+						t.Errorf("duplicate sequence %d", id&(1<<CountBits-1))
+					}
+					ids[id&(1<<CountBits-1)] = true
+					mutex.Unlock()
+				}
+			}(alloc)
+		}
+	}
+	wg.Wait()
+
+	// 批量分配跨越多个号段
+	batch, err := a.NextIds(ctx, "segment", 350)
+	if err != nil || len(batch) != 350 {
+		t.Fatalf("next ids fail, len: %d err: %v", len(batch), err)
+	}
+	for _, id := range batch {
+		if ids[id&(1<<CountBits-1)] {
+			t.Fatalf("duplicate sequence %d", id&(1<<CountBits-1))
+		}
+		ids[id&(1<<CountBits-1)] = true
+	}
+
+	if empty, err := a.NextIds(ctx, "segment", 0); err != nil || empty == nil || len(empty) != 0 {
+		t.Fatalf("expected empty slice, got %v err: %v", empty, err)
+	}
+	if _, err = a.NextIds(ctx, "segment", -1); !errors.Is(err, ErrInvalidCount) {
+		t.Fatalf("expected ErrInvalidCount, got %v", err)
+	}
+
+	// 沿用 NextId 的 key 布局及时间戳编码
+	key := NextidKey + "segment" + time.Now().Format(":2006:01:02")
+	if v, _ := rdb.Get(ctx, key).Int64(); v < int64(len(ids)) {
+		t.Fatalf("expected counter >= %d, got %d", len(ids), v)
+	}
+	if ts := batch[0]>>CountBits + BeginTimestamp; ts < time.Now().Add(-time.Minute).Unix() || ts > time.Now().Unix() {
+		t.Fatalf("unexpected timestamp %d", ts)
+	}
+}
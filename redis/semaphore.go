@@ -0,0 +1,321 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/go-basic/uuid"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	// SemaphoreTimeoutSuffix 可过期信号量许可过期时间后缀，key 为 {name}:permit_timeout
+	SemaphoreTimeoutSuffix = ":permit_timeout"
+	// SemaphorePollInterval 等待许可时未收到释放通知的最长重试间隔
+	SemaphorePollInterval = time.Second
+)
+
+// ErrPermitNotFound 许可不存在或已过期
+var ErrPermitNotFound = errors.New("permit not found")
+
+// ErrInvalidPermits 获取或归还的许可数必须大于0
+var ErrInvalidPermits = errors.New("permits must be positive")
+
+// ErrInvalidLeaseTime 许可租期小于1ms，获取后会被立即回收
+var ErrInvalidLeaseTime = errors.New("lease time must be at least 1ms")
+
+// rTrySetPermitsScript ...
+var rTrySetPermitsScript = redis.NewScript(`
+-- 信号量不存在时才设置许可数
+if (redis.call('exists', KEYS[1]) == 0) then
+    redis.call('SET', KEYS[1], ARGV[1]);
+    redis.call('publish', KEYS[2], ARGV[1]);
+    return 1;
+end;
+return 0;
+`)
+
+// rAddPermitsScript ...
+var rAddPermitsScript = redis.NewScript(`
+-- 增加许可并广播，唤醒等待许可的线程
+local value = redis.call('INCRBY', KEYS[1], ARGV[1]);
+redis.call('publish', KEYS[2], value);
+return value;
+`)
+
+// rAcquireScript ...
+var rAcquireScript = redis.NewScript(`
+-- 可用许可足够：扣减许可
+local value = redis.call('GET', KEYS[1]);
+if (value ~= false) and (tonumber(value) >= tonumber(ARGV[1])) then
+    redis.call('DECRBY', KEYS[1], ARGV[1]);
+    return 0;
+end;
+
+-- 许可不足：返回最长重试间隔
+return tonumber(ARGV[2]);
+`)
+
+// rExpirableAcquireScript ...
+var rExpirableAcquireScript = redis.NewScript(`
+-- 回收已过期的许可
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[3]);
+if #expired > 0 then
+    redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', ARGV[3]);
+    local value = redis.call('INCRBY', KEYS[1], #expired);
+    redis.call('publish', KEYS[3], value);
+end;
+
+-- 仅回收过期许可
+if ARGV[1] == '' then
+    return 0;
+end;
+
+-- 可用许可足够：扣减许可，并记录许可过期时间
+local value = redis.call('GET', KEYS[1]);
+if (value ~= false) and (tonumber(value) >= 1) then
+    redis.call('DECRBY', KEYS[1], 1);
+    redis.call('ZADD', KEYS[2], tonumber(ARGV[3]) + tonumber(ARGV[2]), ARGV[1]);
+    return 0;
+end;
+
+-- 许可不足：返回最近一个许可过期的剩余时间，不超过最长重试间隔
+local wait = tonumber(ARGV[4]);
+local first = redis.call('ZRANGE', KEYS[2], 0, 0, 'WITHSCORES');
+if first[2] ~= nil then
+    local ttl = tonumber(first[2]) - tonumber(ARGV[3]);
+    if ttl < wait then
+        wait = ttl;
+    end;
+end;
+if wait < 1 then
+    wait = 1;
+end;
+return wait;
+`)
+
+// rExpirableReleaseScript ...
+var rExpirableReleaseScript = redis.NewScript(`
+-- 许可存在：归还许可并广播
+if (redis.call('ZREM', KEYS[2], ARGV[1]) == 1) then
+    local value = redis.call('INCRBY', KEYS[1], 1);
+    redis.call('publish', KEYS[3], value);
+    return 1;
+end;
+return 0;
+`)
+
+// rExpirableUpdateScript ...
+var rExpirableUpdateScript = redis.NewScript(`
+-- 许可存在且未过期：重置许可过期时间
+local expireAt = redis.call('ZSCORE', KEYS[2], ARGV[1]);
+if (expireAt ~= false) and (tonumber(expireAt) > tonumber(ARGV[3])) then
+    redis.call('ZADD', KEYS[2], tonumber(ARGV[3]) + tonumber(ARGV[2]), ARGV[1]);
+    return 1;
+end;
+return 0;
+`)
+
+// RSemaphore 分布式信号量
+type RSemaphore struct {
+	rdb  redis.Cmdable
+	name string
+}
+
+// NewRSemaphore ...
+func NewRSemaphore(ctx context.Context, rdb redis.Cmdable, name string) *RSemaphore {
+	if _, err := rdb.Ping(ctx).Result(); err != nil {
+		return nil
+	}
+
+	return &RSemaphore{
+		rdb:  rdb,
+		name: name,
+	}
+}
+
+// TrySetPermits 信号量不存在时设置许可数，已存在时返回 false
+func (s *RSemaphore) TrySetPermits(ctx context.Context, permits int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	ret, err := rTrySetPermitsScript.Run(ctx, s.rdb, []string{s.name, releaseChannel(s.name)}, permits).Result()
+	if err != nil {
+		return false, err
+	}
+	return ret.(int64) == 1, nil
+}
+
+// AddPermits 增加许可数，permits 为负数时减少
+func (s *RSemaphore) AddPermits(ctx context.Context, permits int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return rAddPermitsScript.Run(ctx, s.rdb, []string{s.name, releaseChannel(s.name)}, permits).Err()
+}
+
+// AvailablePermits 可用许可数
+func (s *RSemaphore) AvailablePermits(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	ret, err := s.rdb.Get(ctx, s.name).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return ret, err
+}
+
+// acquire ...
+func (s *RSemaphore) acquire(ctx context.Context, permits int64) (int64, error) {
+	ret, err := rAcquireScript.Run(ctx, s.rdb, []string{s.name}, permits, int64(SemaphorePollInterval/time.Millisecond)).Result()
+	if err != nil {
+		return 0, err
+	}
+	return ret.(int64), nil
+}
+
+// TryAcquire 尝试获取许可，许可不足时立即返回 false
+func (s *RSemaphore) TryAcquire(ctx context.Context, permits int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if permits <= 0 {
+		return false, ErrInvalidPermits
+	}
+	ret, err := s.acquire(ctx, permits)
+	if err != nil {
+		return false, err
+	}
+	return ret == 0, nil
+}
+
+// Acquire 获取许可，许可不足时等待释放通知，直到超时返回 ErrTimeout 或 ctx 取消
+func (s *RSemaphore) Acquire(ctx context.Context, permits int64, timeout time.Duration) error {
+	if permits <= 0 {
+		return ErrInvalidPermits
+	}
+	return waitAcquire(ctx, s.rdb, releaseChannel(s.name), timeout, 0, func() (int64, error) {
+		return s.acquire(ctx, permits)
+	})
+}
+
+// Release 归还许可
+func (s *RSemaphore) Release(ctx context.Context, permits int64) error {
+	if permits <= 0 {
+		return ErrInvalidPermits
+	}
+	return s.AddPermits(ctx, permits)
+}
+
+// RPermitExpirableSemaphore 许可可过期的分布式信号量
+// 每次获取一个带唯一标识的许可，持有者崩溃未归还时许可在租期结束后自动回收
+type RPermitExpirableSemaphore struct {
+	RSemaphore
+	now func() time.Time
+}
+
+// NewRPermitExpirableSemaphore ...
+func NewRPermitExpirableSemaphore(ctx context.Context, rdb redis.Cmdable, name string) *RPermitExpirableSemaphore {
+	s := NewRSemaphore(ctx, rdb, name)
+	if s == nil {
+		return nil
+	}
+
+	return &RPermitExpirableSemaphore{RSemaphore: *s, now: time.Now}
+}
+
+// keys 可用许可数、许可过期时间、许可释放广播频道，均在同一 slot
+func (s *RPermitExpirableSemaphore) keys() []string {
+	return []string{
+		s.name,
+		hashTag(s.name) + SemaphoreTimeoutSuffix,
+		releaseChannel(s.name),
+	}
+}
+
+// acquire permitId 为空时仅回收过期许可
+func (s *RPermitExpirableSemaphore) acquire(ctx context.Context, permitId string, leaseTime time.Duration) (int64, error) {
+	now := s.now().UnixNano() / int64(time.Millisecond)
+	ret, err := rExpirableAcquireScript.Run(ctx, s.rdb, s.keys(), permitId, int64(leaseTime/time.Millisecond), now, int64(SemaphorePollInterval/time.Millisecond)).Result()
+	if err != nil {
+		return 0, err
+	}
+	return ret.(int64), nil
+}
+
+// AvailablePermits 回收过期许可后的可用许可数
+func (s *RPermitExpirableSemaphore) AvailablePermits(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if _, err := s.acquire(ctx, "", 0); err != nil {
+		return 0, err
+	}
+	return s.RSemaphore.AvailablePermits(ctx)
+}
+
+// TryAcquire 尝试获取一个租期为 leaseTime 的许可，许可不足时立即返回空字符串
+func (s *RPermitExpirableSemaphore) TryAcquire(ctx context.Context, leaseTime time.Duration) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if leaseTime < time.Millisecond {
+		return "", ErrInvalidLeaseTime
+	}
+	permitId := uuid.New()
+	ret, err := s.acquire(ctx, permitId, leaseTime)
+	if err != nil || ret != 0 {
+		return "", err
+	}
+	return permitId, nil
+}
+
+// Acquire 获取一个租期为 leaseTime 的许可，许可不足时等待释放或过期，直到超时返回 ErrTimeout 或 ctx 取消
+func (s *RPermitExpirableSemaphore) Acquire(ctx context.Context, leaseTime, timeout time.Duration) (string, error) {
+	if leaseTime < time.Millisecond {
+		return "", ErrInvalidLeaseTime
+	}
+	permitId := uuid.New()
+	err := waitAcquire(ctx, s.rdb, releaseChannel(s.name), timeout, 0, func() (int64, error) {
+		return s.acquire(ctx, permitId, leaseTime)
+	})
+	if err != nil {
+		return "", err
+	}
+	return permitId, nil
+}
+
+// Release 归还许可，许可不存在或已过期时返回 ErrPermitNotFound
+func (s *RPermitExpirableSemaphore) Release(ctx context.Context, permitId string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ret, err := rExpirableReleaseScript.Run(ctx, s.rdb, s.keys(), permitId).Result()
+	if err != nil {
+		return err
+	}
+	if ret.(int64) == 0 {
+		return ErrPermitNotFound
+	}
+	return nil
+}
+
+// UpdateLeaseTime 重置许可租期，许可不存在或已过期时返回 ErrPermitNotFound
+func (s *RPermitExpirableSemaphore) UpdateLeaseTime(ctx context.Context, permitId string, leaseTime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if leaseTime < time.Millisecond {
+		return ErrInvalidLeaseTime
+	}
+	now := s.now().UnixNano() / int64(time.Millisecond)
+	ret, err := rExpirableUpdateScript.Run(ctx, s.rdb, s.keys()[:2], permitId, int64(leaseTime/time.Millisecond), now).Result()
+	if err != nil {
+		return err
+	}
+	if ret.(int64) == 0 {
+		return ErrPermitNotFound
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"testing"
+	"time"
+)
+
+// TestSemaphore ...
+func TestSemaphore(t *testing.T) {
+	rdb := redistest.Run(t).Client()
+	ctx := context.Background()
+	rdb.Del(ctx, "mySemaphore")
+	s := NewRSemaphore(ctx, rdb, "mySemaphore")
+
+	if ok, err := s.TrySetPermits(ctx, 3); err != nil || !ok {
+		t.Fatalf("set permits fail, ok: %v err: %v", ok, err)
+	}
+	if ok, _ := s.TrySetPermits(ctx, 10); ok {
+		t.Fatalf("expected permits already set")
+	}
+
+	if err := s.Acquire(ctx, 2, time.Second); err != nil {
+		t.Fatalf("acquire fail, err: %v", err)
+	}
+	if ok, _ := s.TryAcquire(ctx, 2); ok {
+		t.Fatalf("expected try acquire fail with 1 permit left")
+	}
+
+	// 等待其他线程归还许可
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		s.Release(ctx, 2)
+	}()
+	start := time.Now()
+	if err := s.Acquire(ctx, 3, 3*time.Second); err != nil {
+		t.Fatalf("acquire fail, err: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected wake up on release, waited %v", elapsed)
+	}
+
+	if err := s.Acquire(ctx, 1, 200*time.Millisecond); err == nil {
+		t.Fatalf("expected acquire timeout")
+	}
+	s.Release(ctx, 3)
+	if n, _ := s.AvailablePermits(ctx); n != 3 {
+		t.Fatalf("expected 3 permits, got %d", n)
+	}
+
+	// 负数或0个许可不能创建许可
+	for _, permits := range []int64{-5, 0} {
+		if ok, err := s.TryAcquire(ctx, permits); ok || !errors.Is(err, ErrInvalidPermits) {
+			t.Fatalf("expected ErrInvalidPermits for %d, got ok: %v err: %v", permits, ok, err)
+		}
+		if err := s.Acquire(ctx, permits, time.Second); !errors.Is(err, ErrInvalidPermits) {
+			t.Fatalf("expected ErrInvalidPermits for %d, got %v", permits, err)
+		}
+		if err := s.Release(ctx, permits); !errors.Is(err, ErrInvalidPermits) {
+			t.Fatalf("expected ErrInvalidPermits for %d, got %v", permits, err)
+		}
+	}
+	if n, _ := s.AvailablePermits(ctx); n != 3 {
+		t.Fatalf("expected 3 permits, got %d", n)
+	}
+}
+
+// TestPermitExpirableSemaphore ...
+func TestPermitExpirableSemaphore(t *testing.T) {
+	srv := redistest.Run(t, redistest.WithFrozenClock())
+	rdb := srv.Client()
+	ctx := context.Background()
+	s := NewRPermitExpirableSemaphore(ctx, rdb, "myExpirableSemaphore")
+	s.now = srv.Now
+
+	if ok, err := s.TrySetPermits(ctx, 1); err != nil || !ok {
+		t.Fatalf("set permits fail, ok: %v err: %v", ok, err)
+	}
+
+	// 持有者不归还，许可过期后自动回收
+	crashed, err := s.TryAcquire(ctx, 200*time.Millisecond)
+	if err != nil || crashed == "" {
+		t.Fatalf("acquire fail, err: %v", err)
+	}
+	srv.Advance(199 * time.Millisecond)
+	if id, _ := s.TryAcquire(ctx, time.Second); id != "" {
+		t.Fatalf("expected no permit left")
+	}
+	srv.Advance(time.Millisecond)
+	id, err := s.TryAcquire(ctx, time.Second)
+	if err != nil || id == "" {
+		t.Fatalf("expected expired permit reclaimed, err: %v", err)
+	}
+
+	if err = s.Release(ctx, crashed); !errors.Is(err, ErrPermitNotFound) {
+		t.Fatalf("expected ErrPermitNotFound, got %v", err)
+	}
+	if err = s.UpdateLeaseTime(ctx, id, 2*time.Second); err != nil {
+		t.Fatalf("update lease fail, err: %v", err)
+	}
+
+	// 租期小于1ms的许可会被立即回收
+	for _, leaseTime := range []time.Duration{-time.Second, 0, time.Microsecond} {
+		if _, err = s.TryAcquire(ctx, leaseTime); !errors.Is(err, ErrInvalidLeaseTime) {
+			t.Fatalf("expected ErrInvalidLeaseTime for %v, got %v", leaseTime, err)
+		}
+		if _, err = s.Acquire(ctx, leaseTime, time.Second); !errors.Is(err, ErrInvalidLeaseTime) {
+			t.Fatalf("expected ErrInvalidLeaseTime for %v, got %v", leaseTime, err)
+		}
+		if err = s.UpdateLeaseTime(ctx, id, leaseTime); !errors.Is(err, ErrInvalidLeaseTime) {
+			t.Fatalf("expected ErrInvalidLeaseTime for %v, got %v", leaseTime, err)
+		}
+	}
+	if err = s.Release(ctx, id); err != nil {
+		t.Fatalf("release fail, err: %v", err)
+	}
+	if n, _ := s.AvailablePermits(ctx); n != 1 {
+		t.Fatalf("expected 1 permit, got %d", n)
+	}
+}
+
+// TestSemaphoreCluster ...
+func TestSemaphoreCluster(t *testing.T) {
+	rdb := redistest.Run(t).ClusterClient()
+	ctx := context.Background()
+
+	s := NewRSemaphore(ctx, rdb, "myClusterSemaphore")
+	if ok, err := s.TrySetPermits(ctx, 1); err != nil || !ok {
+		t.Fatalf("set permits fail, ok: %v err: %v", ok, err)
+	}
+	if err := s.Acquire(ctx, 1, time.Second); err != nil {
+		t.Fatalf("acquire fail, err: %v", err)
+	}
+	if err := s.Release(ctx, 1); err != nil {
+		t.Fatalf("release fail, err: %v", err)
+	}
+
+	es := NewRPermitExpirableSemaphore(ctx, rdb, "myClusterExpirableSemaphore")
+	if ok, err := es.TrySetPermits(ctx, 1); err != nil || !ok {
+		t.Fatalf("set permits fail, ok: %v err: %v", ok, err)
+	}
+	id, err := es.Acquire(ctx, time.Second, time.Second)
+	if err != nil {
+		t.Fatalf("acquire fail, err: %v", err)
+	}
+	if err = es.UpdateLeaseTime(ctx, id, 2*time.Second); err != nil {
+		t.Fatalf("update lease fail, err: %v", err)
+	}
+	if err = es.Release(ctx, id); err != nil {
+		t.Fatalf("release fail, err: %v", err)
+	}
+	if n, err := es.AvailablePermits(ctx); err != nil || n != 1 {
+		t.Fatalf("expected 1 permit, got %d err: %v", n, err)
+	}
+}
@@ -0,0 +1,239 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// SnowflakeEpoch 雪花算法开始时间戳(毫秒)，与 BeginTimestamp 一致
+	SnowflakeEpoch = BeginTimestamp * 1000
+	// SnowflakeWorkerBits 机器ID的位数
+	SnowflakeWorkerBits = 10
+	// SnowflakeSequenceBits 毫秒内序列号的位数
+	SnowflakeSequenceBits = 12
+	// SnowflakeWorkerKey 机器ID租约前缀
+	SnowflakeWorkerKey = "snowflake:worker:"
+	// SnowflakeWorkerTTL 机器ID租约过期时间，每隔1/3续期一次
+	SnowflakeWorkerTTL = 30 * time.Second
+	// SnowflakeMaxBackwards 可等待的最大时钟回拨，超过时返回 ErrClockBackwards
+	SnowflakeMaxBackwards = 5 * time.Millisecond
+
+	snowflakeMaxWorker   = 1<<SnowflakeWorkerBits - 1
+	snowflakeMaxSequence = 1<<SnowflakeSequenceBits - 1
+)
+
+var (
+	// ErrClockBackwards 时钟回拨超过 SnowflakeMaxBackwards
+	ErrClockBackwards = errors.New("clock moved backwards")
+	// ErrNoWorkerId 机器ID已全部被占用
+	ErrNoWorkerId = errors.New("no snowflake worker id available")
+	// ErrWorkerLost 机器ID租约丢失，继续生成可能与其他实例重复
+	ErrWorkerLost = errors.New("snowflake worker id lease lost")
+	// ErrSnowflakeClosed 生成器已关闭，机器ID可能已被其他实例占用
+	ErrSnowflakeClosed = errors.New("snowflake closed")
+)
+
+// rWorkerRenewScript ...
+var rWorkerRenewScript = redis.NewScript(`
+-- 租约仍属于当前实例：续期
+if (redis.call('GET', KEYS[1]) == ARGV[2]) then
+    redis.call('PEXPIRE', KEYS[1], ARGV[1]);
+    return 1;
+end;
+
+-- 租约已过期且未被其他实例占用：重新占用
+if (redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[1], 'NX')) then
+    return 1;
+end;
+return 0;
+`)
+
+// rWorkerReleaseScript ...
+var rWorkerReleaseScript = redis.NewScript(`
+if (redis.call('GET', KEYS[1]) == ARGV[1]) then
+    return redis.call('DEL', KEYS[1]);
+end;
+return 0;
+`)
+
+// Snowflake 本地雪花ID生成器，机器ID从redis租用
+// ID结构：41位毫秒时间戳 | 10位机器ID | 12位毫秒内序列号
+type Snowflake struct {
+	rdb      redis.Cmdable
+	key      string
+	token    string
+	workerId int64
+	now      func() time.Time
+
+	mutex    sync.Mutex
+	lastTime int64
+	sequence int64
+	lost     bool
+	closed   bool
+	// renewed 最近一次成功占用或续期租约的时间
+	renewed time.Time
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewSnowflake 按 key 划分机器ID空间，租用一个空闲的机器ID并启动续期
+func NewSnowflake(ctx context.Context, rdb redis.Cmdable, key string) (*Snowflake, error) {
+	s := &Snowflake{
+		rdb:   rdb,
+		key:   key,
+		token: NewOwnerToken(),
+		now:   time.Now,
+		stop:  make(chan struct{}),
+	}
+
+	if err := s.lease(ctx); err != nil {
+		return nil, err
+	}
+
+	go s.heartbeat(context.WithoutCancel(ctx))
+	return s, nil
+}
+
+// workerKey ...
+func (s *Snowflake) workerKey(workerId int64) string {
+	return SnowflakeWorkerKey + s.key + ":" + strconv.FormatInt(workerId, 10)
+}
+
+// lease 依次尝试占用机器ID
+func (s *Snowflake) lease(ctx context.Context) error {
+	for workerId := int64(0); workerId <= snowflakeMaxWorker; workerId++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		start := time.Now()
+		ok, err := s.rdb.SetNX(ctx, s.workerKey(workerId), s.token, SnowflakeWorkerTTL).Result()
+		if err != nil {
+			return err
+		}
+		if ok {
+			s.workerId = workerId
+			s.renewed = start
+			return nil
+		}
+	}
+	return ErrNoWorkerId
+}
+
+// heartbeat 定期续期机器ID租约，直到 Close；租约丢失后生成器不再可用
+func (s *Snowflake) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(SnowflakeWorkerTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// 以发起续期的时间计算租约有效期
+		start := time.Now()
+		ret, err := rWorkerRenewScript.Run(ctx, s.rdb, []string{s.workerKey(s.workerId)}, int64(SnowflakeWorkerTTL/time.Millisecond), s.token).Result()
+		s.mutex.Lock()
+		if err == nil && ret.(int64) == 1 {
+			s.renewed = start
+			s.mutex.Unlock()
+			continue
+		}
+
+		// 被其他实例占用，或网络错误持续到租约过期
+		if err == nil || time.Since(s.renewed) >= SnowflakeWorkerTTL {
+			s.lost = true
+			s.mutex.Unlock()
+			return
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// WorkerId 租用的机器ID
+func (s *Snowflake) WorkerId() int64 {
+	return s.workerId
+}
+
+// NextId 生成雪花ID
+func (s *Snowflake) NextId(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return 0, ErrSnowflakeClosed
+	}
+	// 续期失败时心跳每 TTL/3 才检查一次，租约过期后立即停止生成
+	if s.lost || time.Since(s.renewed) >= SnowflakeWorkerTTL {
+		return 0, ErrWorkerLost
+	}
+
+	now := s.millis()
+	if now < s.lastTime {
+		// 小幅时钟回拨：等待时钟追上上次生成时间
+		backwards := time.Duration(s.lastTime-now) * time.Millisecond
+		if backwards > SnowflakeMaxBackwards {
+			return 0, ErrClockBackwards
+		}
+		time.Sleep(backwards)
+		now = s.millis()
+		if now < s.lastTime {
+			return 0, ErrClockBackwards
+		}
+	}
+
+	if now == s.lastTime {
+		s.sequence = (s.sequence + 1) & snowflakeMaxSequence
+		if s.sequence == 0 {
+			// 毫秒内序列号用尽：等待下一毫秒
+			for now <= s.lastTime {
+				time.Sleep(100 * time.Microsecond)
+				now = s.millis()
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.lastTime = now
+
+	return (now-SnowflakeEpoch)<<(SnowflakeWorkerBits+SnowflakeSequenceBits) | s.workerId<<SnowflakeSequenceBits | s.sequence, nil
+}
+
+// millis ...
+func (s *Snowflake) millis() int64 {
+	return s.now().UnixNano() / int64(time.Millisecond)
+}
+
+// Close 停止续期并释放机器ID，之后 NextId 返回 ErrSnowflakeClosed
+func (s *Snowflake) Close(ctx context.Context) error {
+	s.once.Do(func() {
+		s.mutex.Lock()
+		s.closed = true
+		s.mutex.Unlock()
+		close(s.stop)
+	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return rWorkerReleaseScript.Run(ctx, s.rdb, []string{s.workerKey(s.workerId)}, s.token).Err()
+}
+
+// ParseSnowflakeId 解析雪花ID的生成时间、机器ID、毫秒内序列号
+func ParseSnowflakeId(id int64) (t time.Time, workerId, sequence int64) {
+	millis := id>>(SnowflakeWorkerBits+SnowflakeSequenceBits) + SnowflakeEpoch
+	workerId = id >> SnowflakeSequenceBits & snowflakeMaxWorker
+	sequence = id & snowflakeMaxSequence
+	return time.Unix(0, millis*int64(time.Millisecond)), workerId, sequence
+}
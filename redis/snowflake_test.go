@@ -0,0 +1,138 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSnowflake ...
+func TestSnowflake(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx := context.Background()
+
+	a, err := NewSnowflake(ctx, rdb, "order")
+	if err != nil {
+		t.Fatalf("new snowflake fail, err: %v", err)
+	}
+	b, err := NewSnowflake(ctx, rdb, "order")
+	if err != nil {
+		t.Fatalf("new snowflake fail, err: %v", err)
+	}
+	if a.WorkerId() == b.WorkerId() {
+		t.Fatalf("expected distinct worker ids, got %d", a.WorkerId())
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		ids   = make(map[int64]bool)
+	)
+	for _, g := range []*Snowflake{a, b} {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(g *Snowflake) {
+				defer wg.Done()
+				for j := 0; j < 5000; j++ {
+					id, err := g.NextId(ctx)
+					if err != nil {
+						t.Errorf("next id fail, err: %v", err)
+						return
+					}
+					mutex.Lock()
+					if ids[id] {
+						t.Errorf("duplicate id %d", id)
+					}
+					ids[id] = true
+					mutex.Unlock()
+				}
+			}(g)
+		}
+	}
+	wg.Wait()
+
+	// 解析ID
+	before := time.Now().Add(-time.Millisecond)
+	id, _ := b.NextId(ctx)
+	at, workerId, sequence := ParseSnowflakeId(id)
+	if workerId != b.WorkerId() || sequence < 0 || at.Before(before) || at.After(time.Now()) {
+		t.Fatalf("unexpected parse result time=%v worker=%d sequence=%d", at, workerId, sequence)
+	}
+
+	// 关闭后机器ID可被复用
+	if err = a.Close(ctx); err != nil {
+		t.Fatalf("close fail, err: %v", err)
+	}
+	if _, err = a.NextId(ctx); !errors.Is(err, ErrSnowflakeClosed) {
+		t.Fatalf("expected ErrSnowflakeClosed, got %v", err)
+	}
+	c, err := NewSnowflake(ctx, rdb, "order")
+	if err != nil || c.WorkerId() != a.WorkerId() {
+		t.Fatalf("expected released worker id reused, err: %v", err)
+	}
+	b.Close(ctx)
+	c.Close(ctx)
+}
+
+// TestSnowflakeClockBackwards ...
+func TestSnowflakeClockBackwards(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx := context.Background()
+
+	g, err := NewSnowflake(ctx, rdb, "clock")
+	if err != nil {
+		t.Fatalf("new snowflake fail, err: %v", err)
+	}
+	defer g.Close(ctx)
+
+	now := time.Now()
+	g.now = func() time.Time { return now }
+	first, _ := g.NextId(ctx)
+
+	// 小幅回拨等待追上
+	g.now = func() time.Time {
+		if time.Since(now) < 2*time.Millisecond {
+			return now.Add(-2 * time.Millisecond)
+		}
+		return now.Add(time.Millisecond)
+	}
+	second, err := g.NextId(ctx)
+	if err != nil || second <= first {
+		t.Fatalf("expected increasing id after small rollback, err: %v", err)
+	}
+
+	// 大幅回拨直接报错
+	g.now = func() time.Time { return now.Add(-time.Second) }
+	if _, err = g.NextId(ctx); !errors.Is(err, ErrClockBackwards) {
+		t.Fatalf("expected ErrClockBackwards, got %v", err)
+	}
+}
+
+// TestSnowflakeLeaseExpired ...
+func TestSnowflakeLeaseExpired(t *testing.T) {
+	s := redistest.Run(t)
+	rdb := s.Client()
+	ctx := context.Background()
+
+	g, err := NewSnowflake(ctx, rdb, "lease")
+	if err != nil {
+		t.Fatalf("new snowflake fail, err: %v", err)
+	}
+	defer g.Close(ctx)
+	if _, err = g.NextId(ctx); err != nil {
+		t.Fatalf("next id fail, err: %v", err)
+	}
+
+	// 续期持续失败直到租约过期，心跳尚未检查时同样拒绝生成
+	g.mutex.Lock()
+	g.renewed = time.Now().Add(-SnowflakeWorkerTTL)
+	g.mutex.Unlock()
+	if _, err = g.NextId(ctx); !errors.Is(err, ErrWorkerLost) {
+		t.Fatalf("expected ErrWorkerLost, got %v", err)
+	}
+}
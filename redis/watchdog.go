@@ -0,0 +1,124 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+// ErrLockLost 看门狗续期失败，锁已过期或被他人持有
+var ErrLockLost = errors.New("lock lost")
+
+// rRenewScript ...
+var rRenewScript = redis.NewScript(`
+-- 若锁存在，且唯一标识匹配：则重置锁过期时间
+if (redis.call('HEXISTS', KEYS[1], ARGV[2]) == 1) then
+    redis.call('PEXPIRE', KEYS[1], ARGV[1]);
+    return 1;
+end;
+
+-- 锁不存在或已被其他线程持有：续期失败
+return 0;
+`)
+
+// watchdog 锁续期协程
+type watchdog struct {
+	stop chan struct{}
+	once sync.Once
+}
+
+// close 停止续期协程，可重复调用
+func (dog *watchdog) close() {
+	dog.once.Do(func() {
+		close(dog.stop)
+	})
+}
+
+// renew 续期一次，锁已不属于当前持有者时返回 ErrLockLost
+func (h *LockHandle) renew(ctx context.Context) error {
+	renewal := h.renewal
+	if renewal == nil {
+		renewal = renewLock
+	}
+	ret, err := renewal(ctx, h)
+	if err != nil {
+		return err
+	}
+	if ret.(int64) == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// renewLock 续期互斥锁
+func renewLock(ctx context.Context, h *LockHandle) (interface{}, error) {
+	return rRenewScript.Run(ctx, h.rLock.rdb, []string{h.name}, int64(h.expiration/time.Millisecond), h.token).Result()
+}
+
+// StartWatchdog 启动看门狗，每隔过期时间的1/3续期一次，直到 Unlock、StopWatchdog 或 ctx 取消
+// 续期失败(锁丢失)时向返回的通道写入错误，看门狗停止后通道关闭；重复调用返回 nil
+func (h *LockHandle) StartWatchdog(ctx context.Context) <-chan error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.dog != nil {
+		return nil
+	}
+
+	dog := &watchdog{stop: make(chan struct{})}
+	h.dog = dog
+
+	lost := make(chan error, 1)
+	go h.watch(ctx, dog, lost)
+	return lost
+}
+
+// StopWatchdog 停止看门狗，未启动时无操作
+func (h *LockHandle) StopWatchdog() {
+	h.mu.Lock()
+	dog := h.dog
+	h.mu.Unlock()
+
+	if dog != nil {
+		dog.close()
+	}
+}
+
+// watch ...
+func (h *LockHandle) watch(ctx context.Context, dog *watchdog, lost chan<- error) {
+	defer close(lost)
+
+	ticker := time.NewTicker(h.expiration / 3)
+	defer ticker.Stop()
+
+	// 最近一次续期成功的时间，网络错误时在锁过期前持续重试
+	renewed := time.Now()
+	for {
+		select {
+		case <-dog.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := h.renew(ctx)
+		if err == nil {
+			renewed = time.Now()
+			continue
+		}
+
+		if errors.Is(err, ErrLockLost) || time.Since(renewed) >= h.expiration {
+			h.rLock.logger.Printf("watchdog name=%s field=%s err=%v", h.name, h.token, err)
+			if !errors.Is(err, ErrLockLost) {
+				err = fmt.Errorf("%w: %v", ErrLockLost, err)
+			}
+			lost <- err
+			dog.close()
+			return
+		}
+	}
+}
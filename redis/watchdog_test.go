@@ -0,0 +1,76 @@
+package redisson
+
+import (
+	"context"
+	"errors"
+	"github.com/chenpeicheng3804/go-utils/redis/redistest"
+	"testing"
+	"time"
+)
+
+// TestWatchdog ...
+func TestWatchdog(t *testing.T) {
+	s := redistest.Run(t, redistest.WithFrozenClock())
+	rdb := s.Client()
+	ctx := context.Background()
+	rLock := NewRLock(ctx, rdb)
+
+	expiration := 30 * time.Millisecond
+	l, err := rLock.Lock(ctx, "myWatchdogLock", expiration)
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+
+	// 看门狗续期后过期时间重置
+	s.Advance(20 * time.Millisecond)
+	lost := l.StartWatchdog(ctx)
+	deadline := time.Now().Add(time.Second)
+	for s.TTL("myWatchdogLock") != expiration {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected lock renewed by watchdog, ttl %v", s.TTL("myWatchdogLock"))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 超过首次加锁的过期时间后锁仍被持有
+	s.Advance(25 * time.Millisecond)
+	if !s.Exists("myWatchdogLock") {
+		t.Fatalf("expected lock renewed by watchdog")
+	}
+
+	// 锁过期后看门狗上报锁丢失
+	s.Advance(expiration)
+	select {
+	case err = <-lost:
+		if !errors.Is(err, ErrLockLost) {
+			t.Errorf("expected ErrLockLost, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("expected lock lost notification")
+	}
+}
+
+// TestWatchdogStopOnUnlock ...
+func TestWatchdogStopOnUnlock(t *testing.T) {
+	rdb := redistest.Run(t).Client()
+	ctx := context.Background()
+	rLock := NewRLock(ctx, rdb)
+
+	l, err := rLock.Lock(ctx, "myWatchdogUnlock", 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("lock fail, err: %v", err)
+	}
+	lost := l.StartWatchdog(ctx)
+	if err = l.Unlock(ctx); err != nil {
+		t.Fatalf("unlock fail, err: %v", err)
+	}
+
+	select {
+	case err, ok := <-lost:
+		if ok {
+			t.Errorf("expected watchdog stopped without error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("expected watchdog stopped")
+	}
+}